@@ -38,16 +38,21 @@ securitySchemes:
     bearerFormat: JWT
 
 # Teaches respec the routing syntax of your web framework.
-# Defaults for chi/v5 and gin-gonic/gin are built-in.
+# Defaults for chi/v5, gin-gonic/gin, echo/v4, fiber/v2, gorilla/mux and net/http.ServeMux are built-in.
 # Only uncomment and modify this section if you use a different framework.
 # routerDefinitions:
 #   - type: "github.com/go-chi/chi/v5.Mux"
 #     endpointMethods: ["Get", "Post", "Put", "Patch", "Delete", "Head", "Options", "Trace"]
+#     endpointRegistrations:
+#       - { name: "Method", methodIndex: 0, pathIndex: 1, handlerIndex: 2 }
+#       - { name: "HandleFunc", pathIndex: 0, handlerIndex: 1, anyMethod: true }
 #     groupMethods: ["Route", "Group"]
 #     middlewareWrapperMethods: ["With", "Use"]
+#     mountMethods: ["Mount"]
 
 # Teaches respec to infer details from your project's custom helper functions.
-# Defaults for the standard library and common frameworks are built-in.
+# Defaults for the standard library and common frameworks are built-in; patterns
+# listed here are added to them.
 # handlerPatterns:
 #   requestBody:
 #     - functionPath: "path/to/my/utils.BindRequest"
@@ -186,10 +191,71 @@ framework-agnostic and highly configurable through a .respec.yaml file.`,
 		},
 	}
 
+	// patternsCmd groups commands that work with handler patterns.
+	var patternsCmd = &cobra.Command{
+		Use:   "patterns",
+		Short: "Work with handler inference patterns",
+	}
+
+	// suggestCmd prints handler patterns synthesized from the project's helpers.
+	var suggestCmd = &cobra.Command{
+		Use:   "suggest [path]",
+		Short: "Suggest handler patterns for the project's helper functions",
+		Long: `Summarizes the project's functions and prints request and response body
+patterns for helpers whose parameters flow into known sinks, such as a
+writeJSON(w, status, v) helper that calls WriteHeader and json.Encoder.Encode.
+The output is YAML ready to paste into .respec.yaml, where handler patterns
+are added to the built-in ones. Progress is logged to stderr, so the output
+can be redirected to a file.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			projectPath := "."
+			if len(args) > 0 {
+				projectPath = args[0]
+			}
+
+			cfg, err := config.Load(projectPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading .respec.yaml: %v\n", err)
+				os.Exit(1)
+			}
+
+			// Keep stdout for the YAML.
+			patterns, err := analyzer.SuggestPatterns(projectPath, cfg, os.Stderr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error during analysis: %v\n", err)
+				os.Exit(1)
+			}
+			if len(patterns.RequestBody) == 0 && len(patterns.ResponseBody) == 0 {
+				fmt.Fprintln(os.Stderr, "No helper patterns found.")
+				return
+			}
+
+			suggestion := struct {
+				HandlerPatterns struct {
+					RequestBody  []config.RequestBodyPattern  `yaml:"requestBody,omitempty"`
+					ResponseBody []config.ResponseBodyPattern `yaml:"responseBody,omitempty"`
+				} `yaml:"handlerPatterns"`
+			}{}
+			suggestion.HandlerPatterns.RequestBody = patterns.RequestBody
+			suggestion.HandlerPatterns.ResponseBody = patterns.ResponseBody
+
+			outputData, err := yaml.Marshal(suggestion)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error marshalling patterns: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Println("# Suggested handler patterns for .respec.yaml")
+			fmt.Print(string(outputData))
+		},
+	}
+
 	// Register all commands and flags.
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(validateCmd)
+	patternsCmd.AddCommand(suggestCmd)
+	rootCmd.AddCommand(patternsCmd)
 	rootCmd.Flags().StringVarP(&outputPath, "output", "o", "openapi.yaml", "Output file for the OpenAPI specification (e.g., openapi.yaml or openapi.json)")
 
 	// Execute the root command.
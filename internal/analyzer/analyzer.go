@@ -2,6 +2,8 @@ package analyzer
 
 import (
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/Zachacious/go-respec/internal/config"
 	"github.com/Zachacious/go-respec/internal/model"
@@ -10,22 +12,7 @@ import (
 )
 
 func Analyze(projectPath string, cfg *config.Config) (*model.APIModel, error) {
-	pkgCfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
-			packages.NeedImports | packages.NeedDeps | packages.NeedTypes |
-			packages.NeedSyntax | packages.NeedTypesInfo,
-		Dir: projectPath,
-	}
-
-	pkgs, err := packages.Load(pkgCfg, "./...")
-	if err != nil {
-		return nil, fmt.Errorf("failed to load packages: %w", err)
-	}
-	if packages.PrintErrors(pkgs) > 0 {
-		return nil, fmt.Errorf("packages contain errors")
-	}
-
-	state, err := NewState(pkgs, cfg)
+	state, err := loadState(projectPath, cfg, os.Stdout)
 	if err != nil {
 		return nil, err
 	}
@@ -36,7 +23,7 @@ func Analyze(projectPath string, cfg *config.Config) (*model.APIModel, error) {
 	state.performDataFlowAnalysis()
 	state.analyzeHandlers()
 
-	fmt.Println("✅ Analysis complete. All phases executed successfully.")
+	fmt.Fprintln(state.log, "✅ Analysis complete. All phases executed successfully.")
 
 	apiModel := &model.APIModel{}
 	apiModel.RouteGraph = state.RouteGraph
@@ -48,7 +35,42 @@ func Analyze(projectPath string, cfg *config.Config) (*model.APIModel, error) {
 
 	// This correctly uses the public Components map from the SchemaGenerator.
 	// This ensures that only named, reusable schemas are added to the final spec.
+	state.SchemaGen.MergeRequestComponents()
 	apiModel.Components.Schemas = state.SchemaGen.Components
 
 	return apiModel, nil
 }
+
+// SuggestPatterns summarizes the project's functions and returns the request
+// and response body patterns synthesized for its helpers, for use in
+// `.respec.yaml`. Progress is logged to log.
+func SuggestPatterns(projectPath string, cfg *config.Config, log io.Writer) (*config.HandlerPatternsConfig, error) {
+	state, err := loadState(projectPath, cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	state.discoverUniverse()
+	return state.synthesizePatterns(), nil
+}
+
+// loadState loads the project's packages and creates the analysis state,
+// which logs its progress to log.
+func loadState(projectPath string, cfg *config.Config, log io.Writer) (*State, error) {
+	pkgCfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes |
+			packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir: projectPath,
+	}
+
+	pkgs, err := packages.Load(pkgCfg, "./...")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("packages contain errors")
+	}
+
+	return NewState(pkgs, cfg, log)
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"go/ast"
+	"io"
+	"testing"
+
+	"github.com/Zachacious/go-respec/internal/config"
+	"github.com/Zachacious/go-respec/internal/model"
+)
+
+// loadFixture loads the fixture module in testdata/name and discovers its
+// functions. configure, if set, adjusts the default configuration first.
+func loadFixture(t *testing.T, name string, configure func(*config.Config)) *State {
+	t.Helper()
+	dir := "testdata/" + name
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+	state, err := loadState(dir, cfg, io.Discard)
+	if err != nil {
+		t.Fatalf("loading %s: %v", dir, err)
+	}
+	state.discoverUniverse()
+	return state
+}
+
+// funcBody returns the body of the fixture function with the given name.
+func funcBody(t *testing.T, s *State, name string) *ast.BlockStmt {
+	t.Helper()
+	for obj, funcDecl := range s.Universe.Functions {
+		if obj.Name() == name {
+			return funcDecl.Body
+		}
+	}
+	t.Fatalf("function %s not found", name)
+	return nil
+}
+
+// analyzeFixture analyzes the fixture module in testdata/name with the default
+// configuration and returns its operations.
+func analyzeFixture(t *testing.T, name string) []*model.Operation {
+	t.Helper()
+	dir := "testdata/" + name
+	cfg, err := config.Load(dir)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	apiModel, err := Analyze(dir, cfg)
+	if err != nil {
+		t.Fatalf("analyzing %s: %v", dir, err)
+	}
+
+	var ops []*model.Operation
+	var collect func(node *model.RouteNode)
+	collect = func(node *model.RouteNode) {
+		ops = append(ops, node.Operations...)
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(apiModel.RouteGraph)
+	return ops
+}
@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"sort"
+)
+
+// enumValue is a constant of an enum type.
+type enumValue struct {
+	name  string
+	value any
+}
+
+// registerEnums collects the constants declared for named string and integer
+// types, e.g. `const StatusPending OrderStatus = "pending"`, as the enum values
+// of those types. A type needs at least two constants to count as an enum.
+// Integer enums whose type marshals itself as text through its `String()`
+// method become string enums of the `String()` results.
+func (s *State) registerEnums() {
+	consts := make(map[*types.TypeName][]*types.Const)
+	for obj := range s.Universe.Constants {
+		c, ok := obj.(*types.Const)
+		if !ok {
+			continue
+		}
+		named, ok := c.Type().(*types.Named)
+		if !ok || named.Obj().Pkg() != c.Pkg() {
+			continue
+		}
+		if basic, ok := named.Underlying().(*types.Basic); !ok || basic.Info()&(types.IsString|types.IsInteger) == 0 {
+			continue
+		}
+		consts[named.Obj()] = append(consts[named.Obj()], c)
+	}
+
+	for typeName, cs := range consts {
+		if len(cs) < 2 {
+			continue
+		}
+		// Map iteration order is random; list values in declaration order.
+		sort.Slice(cs, func(i, j int) bool { return cs[i].Pos() < cs[j].Pos() })
+
+		named := typeName.Type().(*types.Named)
+		var names map[*types.Const]string
+		if marshalsItself(named) {
+			if names = s.stringerNames(named); names == nil {
+				continue // The encoded values are unknown.
+			}
+		}
+
+		var values []enumValue
+		seen := make(map[any]bool)
+		for _, c := range cs {
+			var value any
+			switch {
+			case names != nil:
+				name, ok := names[c]
+				if !ok {
+					continue
+				}
+				value = name
+			case c.Val().Kind() == constant.String:
+				value = constant.StringVal(c.Val())
+			default:
+				n, ok := constant.Int64Val(c.Val())
+				if !ok {
+					continue
+				}
+				value = n
+			}
+			if seen[value] {
+				continue // An alias of an earlier constant.
+			}
+			seen[value] = true
+			values = append(values, enumValue{name: c.Name(), value: value})
+		}
+		if len(values) > 1 {
+			s.SchemaGen.enums[typeName] = values
+		}
+	}
+}
+
+// marshalsItself reports whether a type controls its own JSON encoding.
+func marshalsItself(named *types.Named) bool {
+	methods := types.NewMethodSet(types.NewPointer(named))
+	return methods.Lookup(nil, "MarshalText") != nil || methods.Lookup(nil, "MarshalJSON") != nil
+}
+
+// stringerNames returns the names the `String()` method of a type returns for
+// its constants, for methods that switch over the receiver
+// (`case StatusPending: return "pending"`) or index a string array or map
+// with it (`return [...]string{"pending", "paid"}[s]`).
+func (s *State) stringerNames(named *types.Named) map[*types.Const]string {
+	obj, _, _ := types.LookupFieldOrMethod(named, true, named.Obj().Pkg(), "String")
+	funcDecl := s.Universe.Functions[obj]
+	if funcDecl == nil || funcDecl.Body == nil {
+		return nil
+	}
+
+	names := make(map[*types.Const]string)
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CaseClause:
+			if len(n.Body) == 0 {
+				return true
+			}
+			ret, ok := n.Body[0].(*ast.ReturnStmt)
+			if !ok || len(ret.Results) != 1 {
+				return true
+			}
+			name, ok := s.resolveStringValue(ret.Results[0])
+			if !ok {
+				return true
+			}
+			for _, expr := range n.List {
+				if c, ok := s.getObjectForExpr(expr).(*types.Const); ok {
+					names[c] = name
+				}
+			}
+		case *ast.IndexExpr:
+			lit, ok := ast.Unparen(n.X).(*ast.CompositeLit)
+			if !ok {
+				if init, isVar := s.findVarInitializer(s.getObjectForExpr(n.X)).(*ast.CompositeLit); isVar {
+					lit = init
+				}
+			}
+			if lit != nil {
+				s.indexedNames(named, lit, names)
+			}
+		}
+		return true
+	})
+	if len(names) == 0 {
+		return nil
+	}
+	return names
+}
+
+// indexedNames reads the names of a type's constants from a string array,
+// slice or map literal indexed by the constants' values.
+func (s *State) indexedNames(named *types.Named, lit *ast.CompositeLit, names map[*types.Const]string) {
+	byValue := make(map[int64]string)
+	index := int64(0)
+	for _, elt := range lit.Elts {
+		value := elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, ok := s.resolveIntValue(kv.Key)
+			if !ok {
+				continue
+			}
+			index, value = int64(key), kv.Value
+		}
+		if name, ok := s.resolveStringValue(value); ok {
+			byValue[index] = name
+		}
+		index++
+	}
+
+	for obj := range s.Universe.Constants {
+		c, ok := obj.(*types.Const)
+		if !ok || !types.Identical(c.Type(), named) {
+			continue
+		}
+		if n, ok := constant.Int64Val(c.Val()); ok {
+			if name, ok := byValue[n]; ok {
+				names[c] = name
+			}
+		}
+	}
+}
@@ -2,9 +2,11 @@ package analyzer
 
 import (
 	"go/ast"
+	"go/token"
 	"go/types"
 	"slices"
 
+	"github.com/Zachacious/go-respec/internal/config"
 	"github.com/Zachacious/go-respec/internal/model"
 	"golang.org/x/tools/go/ast/astutil"
 )
@@ -12,59 +14,125 @@ import (
 // performDataFlowAnalysis performs data flow analysis on the router initialization sources.
 func (s *State) performDataFlowAnalysis() {
 	initialRouterVars := s.findInitialRouterVars()
+	s.findRouterFactories(initialRouterVars)
+	s.findMountedRouters()
+
+	// Routers that are mounted into another router are processed when their
+	// mount call is reached, so that they inherit the parent's path prefix,
+	// middleware and metadata.
 	var worklist []*types.Var
 	for _, v := range initialRouterVars {
-		worklist = append(worklist, v)
+		if _, isMounted := s.mountedRouters[v]; !isMounted {
+			worklist = append(worklist, v)
+		}
 	}
 
 	for len(worklist) > 0 {
 		v := worklist[0]
 		worklist = worklist[1:]
-		s.findAndProcessUsages(v)
+		s.processRootRouter(v)
+	}
+
+	// A mounted router whose parent was never reached is still reported at the
+	// top level rather than being dropped.
+	for _, v := range initialRouterVars {
+		if attached, isMounted := s.mountedRouters[v]; isMounted && !attached {
+			s.processRootRouter(v)
+		}
 	}
+
+	s.processPackageEndpoints()
 }
 
-// findInitialRouterVars finds the initial router variables.
-func (s *State) findInitialRouterVars() []*types.Var {
-	var initialVars []*types.Var
+// processPackageEndpoints processes calls to package-level registration
+// functions, such as `http.HandleFunc`, which register endpoints on a default
+// router instance (http.DefaultServeMux) rather than on a variable.
+func (s *State) processPackageEndpoints() {
+	// All functions of one definition share a single default router.
+	defaultRouters := make(map[string]*TrackedValue)
+	for _, resolved := range s.ResolvedRouterTypes {
+		defaultRouter := &TrackedValue{RouterDef: resolved.Definition}
+		for _, funcPath := range resolved.Definition.PackageEndpointFunctions {
+			defaultRouters[funcPath] = defaultRouter
+		}
+	}
+	if len(defaultRouters) == 0 {
+		return
+	}
+
 	for _, pkg := range s.pkgs {
 		for _, file := range pkg.Syntax {
 			ast.Inspect(file, func(n ast.Node) bool {
-				assign, ok := n.(*ast.AssignStmt)
+				call, ok := n.(*ast.CallExpr)
 				if !ok {
 					return true
 				}
-				if len(assign.Lhs) != 1 || len(assign.Rhs) != 1 {
-					return true
-				}
-				callExpr, ok := assign.Rhs[0].(*ast.CallExpr)
+				val, ok := defaultRouters[getFuncPath(s.calleeObject(call))]
 				if !ok {
 					return true
 				}
-				info := s.getInfoForNode(callExpr.Fun)
-				if info == nil {
+				if val.Node == nil {
+					val.Node = &model.RouteNode{Parent: s.RouteGraph}
+					s.RouteGraph.Children = append(s.RouteGraph.Children, val.Node)
+				}
+				s.processMethodCall(val, call, file)
+				return true
+			})
+		}
+	}
+}
+
+// processRootRouter attaches a router variable to the root of the route graph
+// and processes all of its usages.
+func (s *State) processRootRouter(v *types.Var) {
+	trackedVal, ok := s.VarValues[v]
+	if !ok {
+		return
+	}
+	node := &model.RouteNode{GoVar: v, Parent: s.RouteGraph}
+	s.RouteGraph.Children = append(s.RouteGraph.Children, node)
+	trackedVal.Node = node
+	s.findAndProcessUsages(v)
+}
+
+// findInitialRouterVars finds the variables that hold a router created by a
+// constructor call. This covers local variables (`r := chi.NewRouter()`),
+// package-level variables (`var router = chi.NewRouter()`), struct fields
+// (`s.router = chi.NewRouter()`) and multi-value assignments
+// (`r, err := newRouter()`).
+func (s *State) findInitialRouterVars() []*types.Var {
+	var initialVars []*types.Var
+	for _, pkg := range s.pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				var lhs, rhs []ast.Expr
+				switch stmt := n.(type) {
+				case *ast.AssignStmt:
+					lhs, rhs = stmt.Lhs, stmt.Rhs
+				case *ast.ValueSpec:
+					for _, name := range stmt.Names {
+						lhs = append(lhs, name)
+					}
+					rhs = stmt.Values
+				default:
 					return true
 				}
 
-				if sig, ok := info.TypeOf(callExpr.Fun).(*types.Signature); ok {
-					if sig.Results().Len() == 1 {
-						if resolvedType := s.isResolvedRouterType(sig.Results().At(0).Type()); resolvedType != nil {
-							if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
-								if obj := info.Defs[ident]; obj != nil {
-									if v, ok := obj.(*types.Var); ok {
-										node := &model.RouteNode{GoVar: v, Parent: s.RouteGraph}
-										s.RouteGraph.Children = append(s.RouteGraph.Children, node)
-										trackedVal := &TrackedValue{
-											Source:    callExpr,
-											RouterDef: resolvedType.Definition,
-											Node:      node,
-										}
-										s.VarValues[v] = trackedVal
-										initialVars = append(initialVars, v)
-									}
-								}
-							}
-						}
+				for i, target := range lhs {
+					var source ast.Expr
+					resultIndex := 0
+					if len(rhs) == len(lhs) {
+						source = rhs[i]
+					} else if len(rhs) == 1 {
+						// A single call producing multiple values, e.g. `r, err := newRouter()`.
+						source = rhs[0]
+						resultIndex = i
+					}
+					if source == nil {
+						continue
+					}
+					if v := s.registerRouterSource(target, source, resultIndex); v != nil {
+						initialVars = append(initialVars, v)
 					}
 				}
 				return true
@@ -74,6 +142,184 @@ func (s *State) findInitialRouterVars() []*types.Var {
 	return initialVars
 }
 
+// registerRouterSource records the variable or struct field on the left side of
+// an assignment as a router if the expression on the right side produces a
+// router, either as the given result of a call or as a composite literal
+// (`&http.ServeMux{}`). It returns the variable if it was newly recorded.
+func (s *State) registerRouterSource(target ast.Expr, source ast.Expr, resultIndex int) *types.Var {
+	info := s.getInfoForNode(source)
+	if info == nil {
+		return nil
+	}
+
+	var sourceType types.Type
+	switch src := source.(type) {
+	case *ast.CallExpr:
+		sig, ok := info.TypeOf(src.Fun).(*types.Signature)
+		if !ok || resultIndex >= sig.Results().Len() {
+			return nil
+		}
+		sourceType = sig.Results().At(resultIndex).Type()
+	case *ast.UnaryExpr:
+		if _, isLit := src.X.(*ast.CompositeLit); !isLit || src.Op != token.AND {
+			return nil
+		}
+		sourceType = info.TypeOf(src)
+	case *ast.CompositeLit:
+		sourceType = info.TypeOf(src)
+	default:
+		return nil
+	}
+	resolvedType := s.isResolvedRouterType(sourceType)
+	if resolvedType == nil || s.isDerivedFromRouter(source) {
+		return nil
+	}
+
+	// For struct fields this resolves to the field's *types.Var.
+	v, ok := s.getObjectForExpr(target).(*types.Var)
+	if !ok || v.Name() == "_" {
+		return nil
+	}
+	// A variable may be assigned a router in several places (e.g. a field set in
+	// two constructors), but its usages must only be processed once.
+	if _, exists := s.VarValues[v]; exists {
+		return nil
+	}
+
+	// The node is attached once we know whether this router is a root or is
+	// mounted into another one.
+	s.VarValues[v] = &TrackedValue{
+		Source:    source,
+		RouterDef: resolvedType.Definition,
+	}
+	return v
+}
+
+// isDerivedFromRouter reports whether an expression is a call chain on an
+// existing router, such as gorilla's `r.PathPrefix("/api").Subrouter()`. Such
+// routers are groups of their parent and are followed from it rather than
+// being treated as new routers.
+func (s *State) isDerivedFromRouter(expr ast.Expr) bool {
+	for {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return false
+		}
+		info := s.getInfoForNode(sel.X)
+		if info == nil || info.Selections[sel] == nil {
+			// A package-level constructor such as `mux.NewRouter()`.
+			return false
+		}
+		if s.isResolvedRouterType(info.TypeOf(sel.X)) != nil {
+			return true
+		}
+		expr = sel.X
+	}
+}
+
+// findRouterFactories finds project functions that build and return a router,
+// e.g. `func (h *UserHandler) Routes() chi.Router { r := chi.NewRouter(); ...; return r }`.
+func (s *State) findRouterFactories(routerVars []*types.Var) {
+	isRouterVar := make(map[types.Object]bool, len(routerVars))
+	for _, v := range routerVars {
+		isRouterVar[v] = true
+	}
+
+	for fnObj, funcDecl := range s.Universe.Functions {
+		if funcDecl.Body == nil {
+			continue
+		}
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			switch stmt := n.(type) {
+			case *ast.FuncLit:
+				// A return inside a closure does not return from the factory.
+				return false
+			case *ast.ReturnStmt:
+				for _, result := range stmt.Results {
+					if v, ok := s.getObjectForExpr(result).(*types.Var); ok && isRouterVar[v] {
+						s.RouterFactories[fnObj] = v
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// findMountedRouters scans the project for mount calls (e.g. chi's `Mount`) and
+// records every router variable that is mounted into another router.
+func (s *State) findMountedRouters() {
+	mountMethods := make(map[string]bool)
+	for _, resolved := range s.ResolvedRouterTypes {
+		for _, m := range resolved.Definition.MountMethods {
+			mountMethods[m] = true
+		}
+	}
+	if len(mountMethods) == 0 {
+		return
+	}
+
+	for _, pkg := range s.pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) < 2 {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || !mountMethods[sel.Sel.Name] {
+					return true
+				}
+				for _, v := range s.resolveMountedRouters(call.Args[1], 0) {
+					s.mountedRouters[v] = false
+				}
+				return true
+			})
+		}
+	}
+}
+
+// resolveMountedRouters resolves the handler argument of a mount call to the
+// router variables it refers to. It follows local variables back to their
+// initializers and calls to router factories to the router they return.
+func (s *State) resolveMountedRouters(expr ast.Expr, depth int) []*types.Var {
+	const maxDepth = 5
+	if expr == nil || depth > maxDepth {
+		return nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return s.resolveMountedRouters(e.X, depth+1)
+	case *ast.CallExpr:
+		if v, ok := s.RouterFactories[s.calleeObject(e)]; ok {
+			return []*types.Var{v}
+		}
+		// Type conversions such as `http.Handler(sub)`.
+		if info := s.getInfoForNode(e.Fun); info != nil && len(e.Args) == 1 {
+			if tv, ok := info.Types[e.Fun]; ok && tv.IsType() {
+				return s.resolveMountedRouters(e.Args[0], depth+1)
+			}
+		}
+	case *ast.Ident, *ast.SelectorExpr:
+		v, ok := s.getObjectForExpr(e).(*types.Var)
+		if !ok {
+			return nil
+		}
+		var routers []*types.Var
+		if _, isRouter := s.VarValues[v]; isRouter {
+			routers = append(routers, v)
+		}
+		// `sub := h.Routes()` aliases the router built inside the factory.
+		return append(routers, s.resolveMountedRouters(s.findVarInitializer(v), depth+1)...)
+	}
+	return nil
+}
+
 // findAndProcessUsages finds and processes the usages of a variable.
 func (s *State) findAndProcessUsages(v *types.Var) {
 	initialValue, ok := s.VarValues[v]
@@ -97,14 +343,34 @@ func (s *State) findAndProcessUsages(v *types.Var) {
 				if len(path) < 2 {
 					return true
 				}
+
+				// For struct fields and qualified package variables the value is
+				// the whole selector (`s.router`), not just the identifier.
+				var usage ast.Expr = ident
+				if sel, ok := path[1].(*ast.SelectorExpr); ok && sel.Sel == ident {
+					usage = sel
+					path = path[1:]
+					if len(path) < 2 {
+						return true
+					}
+				}
 				parent := path[1]
 
-				if selExpr, ok := parent.(*ast.SelectorExpr); ok {
-					if len(path) > 2 {
-						if callExpr, ok := path[2].(*ast.CallExpr); ok && callExpr.Fun == selExpr {
+				switch p := parent.(type) {
+				case *ast.SelectorExpr:
+					if p.X == usage && len(path) > 2 {
+						if callExpr, ok := path[2].(*ast.CallExpr); ok && callExpr.Fun == p {
 							s.processMethodCall(initialValue, callExpr, file)
 						}
 					}
+				case *ast.CallExpr:
+					// The router is passed as an argument, e.g. `users.RegisterRoutes(r)`.
+					for i, arg := range p.Args {
+						if arg == usage {
+							s.processCallArgument(initialValue, p, i)
+							break
+						}
+					}
 				}
 				return true
 			})
@@ -121,15 +387,26 @@ func (s *State) processMethodCall(currentValue *TrackedValue, call *ast.CallExpr
 	methodName := selExpr.Sel.Name
 	routerDef := currentValue.RouterDef
 
-	if slices.Contains(routerDef.EndpointMethods, methodName) {
-		var handlerFuncDecl *ast.FuncDecl
-		if len(call.Args) >= 2 {
-			handlerObj := s.getObjectForExpr(call.Args[1])
-			if handlerObj != nil {
-				handlerFuncDecl = s.Universe.Functions[handlerObj]
+	if slices.Contains(routerDef.MountMethods, methodName) {
+		s.processMount(currentValue, call)
+		return
+	}
+
+	if reg := endpointRegistration(routerDef, methodName); reg != nil {
+		// Table-driven registrations in a loop produce one route per element.
+		if expansions, ok := s.expandRangeArgs(call); ok {
+			for _, args := range expansions {
+				s.buildRouteFromCall(currentValue, call, args, reg)
 			}
+			return
 		}
-		s.buildRouteFromCall(currentValue, call, handlerFuncDecl)
+		s.buildRouteFromCall(currentValue, call, call.Args, reg)
+		return
+	}
+
+	if slices.Contains(routerDef.SubrouterMethods, methodName) {
+		// e.g. gorilla's `r.PathPrefix("/api").Subrouter()` keeps the scope of the group.
+		s.propagateResult(currentValue, call, file)
 		return
 	}
 
@@ -144,6 +421,22 @@ func (s *State) processMethodCall(currentValue *TrackedValue, call *ast.CallExpr
 			}
 		}
 
+		path, found := s.findPathToNode(call)
+
+		// `r.Use(mw)` as a statement applies the middleware to the receiver's own
+		// scope, and therefore to everything registered or mounted on it.
+		if isMiddlewareMethod && found && len(path) > 1 {
+			if _, isStmt := path[1].(*ast.ExprStmt); isStmt {
+				for _, arg := range call.Args {
+					if middlewareObj := s.getObjectForExpr(arg); middlewareObj != nil {
+						inferredSchemes := s.analyzeMiddleware(middlewareObj)
+						currentValue.Node.InferredSecurity = append(currentValue.Node.InferredSecurity, inferredSchemes...)
+					}
+				}
+				return
+			}
+		}
+
 		newNode := &model.RouteNode{PathPrefix: pathPrefix, Parent: currentValue.Node}
 		currentValue.Node.Children = append(currentValue.Node.Children, newNode)
 		newVal := &TrackedValue{
@@ -154,40 +447,250 @@ func (s *State) processMethodCall(currentValue *TrackedValue, call *ast.CallExpr
 			Node:       newNode,
 		}
 
-		if isMiddlewareMethod {
-			for _, arg := range call.Args {
+		s.propagateResult(newVal, call, file)
+
+		for i, arg := range call.Args {
+			if fn, fnType := s.callbackForExpr(arg); fn != nil {
+				if paramObj := s.paramVar(fnType, 0); paramObj != nil && s.acceptsRouter(paramObj.Type(), routerDef) {
+					newNode.GoVar = paramObj
+					s.trackRouterParam(fn, paramObj, newVal)
+					continue
+				}
+			}
+			// Group methods may take middleware after their prefix, e.g. echo's
+			// `e.Group("/v1", auth)`.
+			if isMiddlewareMethod || (isGroupMethod && i > 0) {
 				if middlewareObj := s.getObjectForExpr(arg); middlewareObj != nil {
 					inferredSchemes := s.analyzeMiddleware(middlewareObj)
 					newNode.InferredSecurity = append(newNode.InferredSecurity, inferredSchemes...)
 				}
 			}
 		}
+	}
+}
 
-		path, found := s.findPathToNode(call)
-		if found && len(path) > 1 {
-			parent := path[1]
-			if parentSel, ok := parent.(*ast.SelectorExpr); ok && parentSel.X == call {
-				if len(path) > 2 {
-					if parentCall, ok := path[2].(*ast.CallExpr); ok && parentCall.Fun == parentSel {
-						s.processMethodCall(newVal, parentCall, file)
-					}
+// acceptsRouter reports whether a parameter of type t can receive a router
+// described by def: the router type itself, or an interface exposing its
+// routing methods such as chi.Router. This tells group callbacks apart from
+// middleware like `func(next http.Handler) http.Handler`.
+func (s *State) acceptsRouter(t types.Type, def *config.RouterDefinition) bool {
+	if s.isResolvedRouterType(t) != nil {
+		return true
+	}
+	iface, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		name := iface.Method(i).Name()
+		if endpointRegistration(def, name) != nil || slices.Contains(def.GroupMethods, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// propagateResult follows the router produced by a group, middleware or
+// subrouter call into wherever the result flows: a chained method call
+// (`r.With(mw).Get(...)`), the variable or field it is assigned to
+// (`api := r.PathPrefix("/api").Subrouter()`), or a project function it is
+// passed to.
+func (s *State) propagateResult(val *TrackedValue, call *ast.CallExpr, file *ast.File) {
+	path, found := s.findPathToNode(call)
+	if !found || len(path) < 2 {
+		return
+	}
+
+	switch parent := path[1].(type) {
+	case *ast.SelectorExpr:
+		if parent.X == call && len(path) > 2 {
+			if parentCall, ok := path[2].(*ast.CallExpr); ok && parentCall.Fun == parent {
+				s.processMethodCall(val, parentCall, file)
+			}
+		}
+	case *ast.AssignStmt:
+		if len(parent.Lhs) == len(parent.Rhs) {
+			for i, rhs := range parent.Rhs {
+				if rhs == call {
+					s.trackAssignedRouter(val, parent.Lhs[i])
 				}
 			}
 		}
-
-		for _, arg := range call.Args {
-			if funcLit, ok := arg.(*ast.FuncLit); ok {
-				if len(funcLit.Type.Params.List) > 0 && len(funcLit.Type.Params.List[0].Names) > 0 {
-					paramIdent := funcLit.Type.Params.List[0].Names[0]
-					if info := s.getInfoForNode(paramIdent); info != nil {
-						if paramObj, ok := info.Defs[paramIdent].(*types.Var); ok {
-							newNode.GoVar = paramObj
-							s.VarValues[paramObj] = newVal
-							s.findAndProcessUsages(paramObj)
-						}
-					}
+	case *ast.ValueSpec:
+		if len(parent.Names) == len(parent.Values) {
+			for i, value := range parent.Values {
+				if value == call {
+					s.trackAssignedRouter(val, parent.Names[i])
 				}
 			}
 		}
+	case *ast.CallExpr:
+		for i, arg := range parent.Args {
+			if arg == call {
+				s.processCallArgument(val, parent, i)
+			}
+		}
+	}
+}
+
+// trackAssignedRouter binds a derived router (e.g. a group) to the variable or
+// struct field it is assigned to and processes that variable's usages.
+func (s *State) trackAssignedRouter(val *TrackedValue, target ast.Expr) {
+	v, ok := s.getObjectForExpr(target).(*types.Var)
+	if !ok || v.Name() == "_" || s.processed[target] {
+		return
+	}
+	if val.Node.GoVar == nil {
+		val.Node.GoVar = v
+	}
+
+	// Guards against reassignments such as `r = r.With(mw)`.
+	s.processed[target] = true
+	defer delete(s.processed, target)
+
+	prev, hadPrev := s.VarValues[v]
+	s.VarValues[v] = val
+	s.findAndProcessUsages(v)
+	if hadPrev {
+		s.VarValues[v] = prev
+	} else {
+		delete(s.VarValues, v)
+	}
+}
+
+// processMount attaches the routers mounted by a call such as
+// `api.Mount("/users", userHandler.Routes())` as children of the current node.
+func (s *State) processMount(currentValue *TrackedValue, call *ast.CallExpr) {
+	if len(call.Args) < 2 {
+		return
+	}
+	pathPrefix, _ := s.resolveStringValue(call.Args[0])
+
+	for _, v := range s.resolveMountedRouters(call.Args[1], 0) {
+		mountedVal, ok := s.VarValues[v]
+		if !ok || s.processed[mountedVal.Source] {
+			continue
+		}
+
+		newNode := &model.RouteNode{GoVar: v, PathPrefix: pathPrefix, Parent: currentValue.Node}
+		currentValue.Node.Children = append(currentValue.Node.Children, newNode)
+		newVal := &TrackedValue{
+			Source:     mountedVal.Source,
+			RouterDef:  mountedVal.RouterDef,
+			Parent:     currentValue,
+			PathPrefix: pathPrefix,
+			Node:       newNode,
+		}
+		s.mountedRouters[v] = true
+
+		// The same router may be mounted more than once, so the value is only
+		// swapped in while its usages are processed.
+		s.processed[mountedVal.Source] = true
+		s.VarValues[v] = newVal
+		s.findAndProcessUsages(v)
+		s.VarValues[v] = mountedVal
+		delete(s.processed, mountedVal.Source)
+	}
+}
+
+// processCallArgument follows a tracked router into a project function it is
+// passed to, binding it to the matching parameter. The function body gets its
+// own RouteNode so that `respec.Meta` calls inside it stay scoped to it.
+func (s *State) processCallArgument(currentValue *TrackedValue, call *ast.CallExpr, argIndex int) {
+	funcDecl := s.funcDeclForCall(call)
+	if funcDecl == nil || funcDecl.Body == nil {
+		return
+	}
+	paramObj := s.paramVar(funcDecl.Type, argIndex)
+	if paramObj == nil {
+		return
+	}
+
+	newNode := &model.RouteNode{GoVar: paramObj, Parent: currentValue.Node}
+	currentValue.Node.Children = append(currentValue.Node.Children, newNode)
+	newVal := &TrackedValue{
+		Source:    call,
+		RouterDef: currentValue.RouterDef,
+		Parent:    currentValue,
+		Node:      newNode,
+	}
+	s.trackRouterParam(funcDecl, paramObj, newVal)
+}
+
+// trackRouterParam binds a tracked value to a function parameter and processes
+// its usages. fn is the function that owns the parameter; it is marked as being
+// processed for the duration of the call so that recursive registration
+// functions do not loop forever.
+func (s *State) trackRouterParam(fn ast.Node, paramObj *types.Var, val *TrackedValue) {
+	if s.processed[fn] {
+		return
+	}
+	s.processed[fn] = true
+	defer delete(s.processed, fn)
+
+	s.VarValues[paramObj] = val
+	s.findAndProcessUsages(paramObj)
+}
+
+// callbackForExpr resolves an argument of a group method to the function that
+// will be invoked with the new router. It handles both inline function literals
+// and references to project functions (e.g. `r.Route("/users", users.Routes)`).
+func (s *State) callbackForExpr(expr ast.Expr) (ast.Node, *ast.FuncType) {
+	if funcLit, ok := expr.(*ast.FuncLit); ok {
+		return funcLit, funcLit.Type
+	}
+	obj := s.getObjectForExpr(expr)
+	if obj == nil {
+		return nil, nil
+	}
+	if funcDecl, ok := s.Universe.Functions[obj]; ok && funcDecl.Body != nil {
+		return funcDecl, funcDecl.Type
+	}
+	return nil, nil
+}
+
+// funcDeclForCall returns the declaration of the project function invoked by a call.
+func (s *State) funcDeclForCall(call *ast.CallExpr) *ast.FuncDecl {
+	obj := s.calleeObject(call)
+	if obj == nil {
+		return nil
+	}
+	return s.Universe.Functions[obj]
+}
+
+// paramVar returns the parameter object that receives the argument at argIndex.
+// Arguments past the last parameter map onto a trailing variadic parameter.
+func (s *State) paramVar(fnType *ast.FuncType, argIndex int) *types.Var {
+	if fnType == nil || fnType.Params == nil {
+		return nil
+	}
+	var names []*ast.Ident
+	for _, field := range fnType.Params.List {
+		if len(field.Names) == 0 {
+			// An unnamed parameter can't be referenced in the body.
+			names = append(names, nil)
+			continue
+		}
+		names = append(names, field.Names...)
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	if argIndex >= len(names) {
+		lastField := fnType.Params.List[len(fnType.Params.List)-1]
+		if _, isVariadic := lastField.Type.(*ast.Ellipsis); !isVariadic {
+			return nil
+		}
+		argIndex = len(names) - 1
+	}
+	ident := names[argIndex]
+	if ident == nil || ident.Name == "_" {
+		return nil
+	}
+	info := s.getInfoForNode(ident)
+	if info == nil {
+		return nil
 	}
+	paramObj, _ := info.Defs[ident].(*types.Var)
+	return paramObj
 }
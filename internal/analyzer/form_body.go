@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"go/ast"
+	"slices"
+
+	"github.com/Zachacious/go-respec/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	mediaTypeMultipart      = "multipart/form-data"
+	mediaTypeFormURLEncoded = "application/x-www-form-urlencoded"
+)
+
+// findFormBody finds the fields a handler reads from a form request body and
+// returns the body's schema and media type. The body is multipart/form-data if
+// a file is read or the form is parsed as multipart, and
+// application/x-www-form-urlencoded otherwise. It returns nil if no form
+// field is read.
+func (s *State) findFormBody(body *ast.BlockStmt, patterns []config.FormFieldPattern) (*openapi3.Schema, string) {
+	schema := openapi3.NewObjectSchema()
+	schema.Properties = make(openapi3.Schemas)
+	multipart := false
+
+	s.inspectCalls(body, &callEnv{}, func(call *ast.CallExpr, env *callEnv) bool {
+		funcPath := getFuncPath(s.getObjectForExpr(call.Fun))
+		if funcPath == "" {
+			return false
+		}
+		for _, p := range patterns {
+			if funcPath != p.FunctionPath {
+				continue
+			}
+			if p.Multipart {
+				multipart = true
+				return true
+			}
+			if len(call.Args) <= p.NameIndex {
+				return true
+			}
+			name, ok := s.resolveStringValueInEnv(call.Args[p.NameIndex], env)
+			if !ok {
+				return true
+			}
+
+			field, found := schema.Properties[name]
+			if !found {
+				if p.File {
+					multipart = true
+					field = openapi3.NewStringSchema().WithFormat("binary").NewRef()
+				} else {
+					field = openapi3.NewStringSchema().NewRef()
+				}
+				schema.Properties[name] = field
+			}
+			if !p.File && field.Value.Type.Is("string") && field.Value.Format == "" {
+				// Form values are typed like query parameters, e.g. `strconv.Atoi(r.FormValue("age"))`.
+				s.inferValueType(call, field.Value)
+			}
+			if !slices.Contains(schema.Required, name) && s.isRequiredValue(call) {
+				schema.Required = append(schema.Required, name)
+			}
+			return true
+		}
+		return false
+	})
+
+	if len(schema.Properties) == 0 {
+		return nil, ""
+	}
+	if multipart {
+		return schema, mediaTypeMultipart
+	}
+	return schema, mediaTypeFormURLEncoded
+}
@@ -28,16 +28,16 @@ func (s *State) FindGroupMetadata() {
 				}
 
 				if len(metaCall.Args) == 1 {
-					routerVarIdent, ok := metaCall.Args[0].(*ast.Ident)
-					if !ok {
+					// The router may be a variable (`r`) or a struct field (`s.router`).
+					switch metaCall.Args[0].(type) {
+					case *ast.Ident, *ast.SelectorExpr:
+					default:
 						return true
 					}
 
-					if info := s.getInfoForNode(routerVarIdent); info != nil {
-						if routerVarObj := info.Uses[routerVarIdent]; routerVarObj != nil {
-							s.GroupMetadata[routerVarObj] = builder
-							return false
-						}
+					if routerVarObj := s.getObjectForExpr(metaCall.Args[0]); routerVarObj != nil {
+						s.GroupMetadata[routerVarObj] = builder
+						return false
 					}
 				}
 				return true
@@ -3,11 +3,11 @@ package analyzer
 import (
 	"fmt"
 	"go/ast"
-	"go/token"
 	"go/types"
 	"maps"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Zachacious/go-respec/internal/config"
 	"github.com/Zachacious/go-respec/internal/model"
@@ -17,10 +17,16 @@ import (
 type responseInfo struct {
 	Type        types.Type
 	Description string
+	// MediaType is the content type of the body, application/json if empty.
+	MediaType string
+	// Binary marks a body of raw bytes without a Go type.
+	Binary bool
+	// Headers are the names of response headers set alongside the response.
+	Headers []string
 }
 
 func (s *State) analyzeHandlers() {
-	fmt.Println("Phase 5: Analyzing handlers and generating schemas...")
+	fmt.Fprintln(s.log, "Phase 5: Analyzing handlers and generating schemas...")
 	s.traverseAndAnalyze(s.RouteGraph)
 }
 
@@ -40,16 +46,16 @@ func (s *State) analyzeHandlerBody(op *model.Operation) {
 		op.Spec = openapi3.NewOperation()
 	}
 
-	funcDecl, ok := s.Universe.Functions[op.GoHandler]
-	if !ok {
+	body := op.HandlerBody
+	if body == nil {
 		op.Spec.Summary = op.HandlerName
 		op.Spec.AddResponse(200, openapi3.NewResponse().WithDescription("Successful response"))
 		return
 	}
 
 	// --- Layer 2: Doc Comment Inference ---
-	if funcDecl.Doc != nil {
-		if parsedComment := parseDocComment(funcDecl.Doc); parsedComment != nil {
+	if op.HandlerDoc != nil {
+		if parsedComment := parseDocComment(op.HandlerDoc); parsedComment != nil {
 			op.Spec.Summary = parsedComment.Summary
 			op.Spec.Description = parsedComment.Description
 		}
@@ -59,12 +65,19 @@ func (s *State) analyzeHandlerBody(op *model.Operation) {
 	}
 
 	// --- Layer 3: Type Inference ---
-	reqType := s.findRequestSchema(funcDecl.Body, s.Config.HandlerPatterns.RequestBody)
+	reqType := s.findRequestSchema(body, s.Config.HandlerPatterns.RequestBody, s.Config.HandlerPatterns.QueryStruct)
 	if reqType != nil {
-		schemaRef := s.SchemaGen.GenerateSchema(reqType)
+		schemaRef := s.SchemaGen.GenerateRequestSchema(reqType)
 		reqBody := openapi3.NewRequestBody().WithContent(openapi3.NewContentWithJSONSchemaRef(schemaRef))
 		op.Spec.RequestBody = &openapi3.RequestBodyRef{Value: reqBody}
 	}
+	if formSchema, mediaType := s.findFormBody(body, s.Config.HandlerPatterns.FormField); formSchema != nil {
+		// A form body is offered next to any JSON body the handler decodes.
+		if op.Spec.RequestBody == nil {
+			op.Spec.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithContent(openapi3.NewContent())}
+		}
+		op.Spec.RequestBody.Value.Content[mediaType] = openapi3.NewMediaType().WithSchema(formSchema)
+	}
 
 	pathParamNames := make(map[string]bool)
 	for _, p := range op.Spec.Parameters {
@@ -72,40 +85,31 @@ func (s *State) analyzeHandlerBody(op *model.Operation) {
 			pathParamNames[p.Value.Name] = true
 		}
 	}
-	queryParams := s.findParametersByPattern(funcDecl.Body, s.Config.HandlerPatterns.QueryParameter, "query", pathParamNames)
-	headerParams := s.findParametersByPattern(funcDecl.Body, s.Config.HandlerPatterns.HeaderParameter, "header", nil)
+	queryParams := s.findParametersByPattern(body, s.Config.HandlerPatterns.QueryParameter, "query", pathParamNames)
+	headerParams := s.findParametersByPattern(body, s.Config.HandlerPatterns.HeaderParameter, "header", nil)
+	// Parameters read individually take precedence over struct-bound ones.
+	boundExclusions := maps.Clone(pathParamNames)
 	for _, p := range queryParams {
 		op.Spec.AddParameter(p.Value)
+		boundExclusions[p.Value.Name] = true
+	}
+	for _, p := range s.findQueryStructParameters(body, s.Config.HandlerPatterns.QueryStruct, boundExclusions) {
+		op.Spec.AddParameter(p.Value)
 	}
 	for _, p := range headerParams {
 		op.Spec.AddParameter(p.Value)
 	}
 
-	responses := s.findResponseSchemas(funcDecl.Body, s.Config.HandlerPatterns.ResponseBody)
-	for statusCode, info := range responses {
-		var schemaRef *openapi3.SchemaRef
-		if info.Type != nil {
-			schemaRef = s.SchemaGen.GenerateSchema(info.Type)
-		}
-		desc := info.Description
-		if desc == "" {
-			desc = http.StatusText(statusCode)
-		}
-		if desc == "" {
-			desc = "Response"
-		}
-		response := openapi3.NewResponse().WithDescription(desc)
-		if statusCode != 204 && schemaRef != nil {
-			response.WithContent(openapi3.NewContentWithJSONSchemaRef(schemaRef))
-		}
-		op.Spec.AddResponse(statusCode, response)
+	responses := s.findResponseSchemas(body, s.Config.HandlerPatterns.ResponseBody)
+	for statusCode, infos := range responses {
+		op.Spec.AddResponse(statusCode, s.buildResponse(statusCode, infos))
 	}
 
 	// --- Layer 1: Apply Explicit Overrides ---
 	if metadata, ok := s.OperationMetadata[op.GoHandler]; ok {
 		if metadata.RequestBodyExpr != nil {
 			if tv, ok := s.getInfoForNode(metadata.RequestBodyExpr).Types[metadata.RequestBodyExpr]; ok {
-				schemaRef := s.SchemaGen.GenerateSchema(tv.Type)
+				schemaRef := s.SchemaGen.GenerateRequestSchema(tv.Type)
 				reqBody := openapi3.NewRequestBody().WithContent(openapi3.NewContentWithJSONSchemaRef(schemaRef))
 				op.Spec.RequestBody = &openapi3.RequestBodyRef{Value: reqBody}
 			}
@@ -140,6 +144,12 @@ func (s *State) analyzeHandlerBody(op *model.Operation) {
 				if resp.Value.Headers == nil {
 					resp.Value.Headers = make(map[string]*openapi3.HeaderRef)
 				}
+				// The override replaces an inferred header spelled differently.
+				for name := range resp.Value.Headers {
+					if containsHeader([]string{name}, headerOverride.Name) {
+						delete(resp.Value.Headers, name)
+					}
+				}
 				resp.Value.Headers[headerOverride.Name] = &openapi3.HeaderRef{
 					Value: &openapi3.Header{
 						Parameter: openapi3.Parameter{Description: headerOverride.Description},
@@ -176,20 +186,86 @@ func (s *State) analyzeHandlerBody(op *model.Operation) {
 	}
 }
 
-// findParametersByPattern finds parameters based on a given pattern.
-func (s *State) findParametersByPattern(body *ast.BlockStmt, patterns []config.ParameterPattern, in string, exclusions map[string]bool) []*openapi3.ParameterRef {
-	var params []*openapi3.ParameterRef
-	foundParams := make(map[string]bool)
+// buildResponse builds the response for a status code from the bodies written
+// for it, grouped by media type. Different bodies of one media type, written on
+// different paths, are combined with oneOf.
+func (s *State) buildResponse(statusCode int, infos []responseInfo) *openapi3.Response {
+	var desc string
+	var mediaTypes []string
+	schemaRefs := make(map[string][]*openapi3.SchemaRef)
+	var headers []string
+	for _, info := range infos {
+		if desc == "" {
+			desc = info.Description
+		}
+		for _, header := range info.Headers {
+			if !containsHeader(headers, header) {
+				headers = append(headers, header)
+			}
+		}
 
-	ast.Inspect(body, func(n ast.Node) bool {
-		call, ok := n.(*ast.CallExpr)
-		if !ok {
-			return true
+		mediaType := info.MediaType
+		var schemaRef *openapi3.SchemaRef
+		switch {
+		case info.Type != nil:
+			schemaRef = s.SchemaGen.GenerateSchema(info.Type)
+		case info.Binary:
+			schemaRef = openapi3.NewStringSchema().WithFormat("binary").NewRef()
+		default:
+			continue
 		}
+		if mediaType == "" {
+			mediaType = mediaTypeJSON
+		}
+		if _, ok := schemaRefs[mediaType]; !ok {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		schemaRefs[mediaType] = append(schemaRefs[mediaType], schemaRef)
+	}
 
+	if desc == "" {
+		desc = http.StatusText(statusCode)
+	}
+	if desc == "" {
+		desc = "Response"
+	}
+	response := openapi3.NewResponse().WithDescription(desc)
+
+	if statusCode != 204 && len(mediaTypes) > 0 {
+		content := openapi3.NewContent()
+		for _, mediaType := range mediaTypes {
+			refs := schemaRefs[mediaType]
+			schemaRef := refs[0]
+			if len(refs) > 1 {
+				// Different bodies are written for the same status on different paths.
+				schemaRef = &openapi3.SchemaRef{Value: &openapi3.Schema{OneOf: refs}}
+			}
+			content[mediaType] = openapi3.NewMediaType().WithSchemaRef(schemaRef)
+		}
+		response.WithContent(content)
+	}
+
+	for _, header := range headers {
+		if response.Headers == nil {
+			response.Headers = make(openapi3.Headers)
+		}
+		response.Headers[header] = &openapi3.HeaderRef{
+			Value: &openapi3.Header{Parameter: openapi3.Parameter{Schema: openapi3.NewStringSchema().NewRef()}},
+		}
+	}
+	return response
+}
+
+// findParametersByPattern finds parameters based on a given pattern, including
+// ones read by project helpers called from the handler.
+func (s *State) findParametersByPattern(body *ast.BlockStmt, patterns []config.ParameterPattern, in string, exclusions map[string]bool) []*openapi3.ParameterRef {
+	var params []*openapi3.ParameterRef
+	foundParams := make(map[string]*openapi3.Parameter)
+
+	s.inspectCalls(body, &callEnv{}, func(call *ast.CallExpr, env *callEnv) bool {
 		info := s.getInfoForNode(call.Fun)
 		if info == nil {
-			return true
+			return false
 		}
 
 		var obj types.Object
@@ -199,10 +275,10 @@ func (s *State) findParametersByPattern(body *ast.BlockStmt, patterns []config.P
 		case *ast.Ident:
 			obj = info.Uses[fun]
 		default:
-			return true
+			return false
 		}
 		if obj == nil {
-			return true
+			return false
 		}
 
 		// Before asserting the type, we must check it. An object could be a
@@ -219,162 +295,117 @@ func (s *State) findParametersByPattern(body *ast.BlockStmt, patterns []config.P
 
 		// If we didn't find a function signature, we can't proceed with this object.
 		if !isSignature {
-			return true
+			return false
 		}
 
 		var funcPath string
 		if recv := funcSignature.Recv(); recv != nil {
-			funcPath = recv.Type().String() + "." + obj.Name()
+			// Pointer receivers are matched without their '*', as in getFuncPath.
+			funcPath = strings.TrimPrefix(recv.Type().String(), "*") + "." + obj.Name()
 		} else if obj.Pkg() != nil {
 			funcPath = obj.Pkg().Path() + "." + obj.Name()
 		} else {
-			return true
+			return false
 		}
 
 		for _, p := range patterns {
-			if funcPath == p.FunctionPath {
-				if len(call.Args) > p.NameIndex {
-					arg := call.Args[p.NameIndex]
-					if key, ok := arg.(*ast.BasicLit); ok && key.Kind == token.STRING {
-						paramName, err := strconv.Unquote(key.Value)
-						if err != nil {
-							return true
-						}
-
-						if !foundParams[paramName] && (exclusions == nil || !exclusions[paramName]) {
-							var param *openapi3.Parameter
-							switch in {
-							case "query":
-								param = openapi3.NewQueryParameter(paramName)
-							case "header":
-								param = openapi3.NewHeaderParameter(paramName)
-							default:
-								return true
-							}
-							param.WithSchema(openapi3.NewStringSchema())
-							params = append(params, &openapi3.ParameterRef{Value: param})
-							foundParams[paramName] = true
-						}
-					}
+			if funcPath != p.FunctionPath || len(call.Args) <= p.NameIndex {
+				continue
+			}
+			// The name may be passed in by the caller of a helper, e.g.
+			// `queryInt(r, "limit")`.
+			paramName, ok := s.resolveStringValueInEnv(call.Args[p.NameIndex], env)
+			if !ok {
+				return true
+			}
+
+			if exclusions != nil && exclusions[paramName] {
+				return true
+			}
+			param, found := foundParams[paramName]
+			if !found {
+				switch in {
+				case "query":
+					param = openapi3.NewQueryParameter(paramName)
+				case "header":
+					param = openapi3.NewHeaderParameter(paramName)
+				default:
+					return true
 				}
+				param.WithSchema(openapi3.NewStringSchema())
+				params = append(params, &openapi3.ParameterRef{Value: param})
+				foundParams[paramName] = param
 			}
+
+			// Every read of the parameter may tell more about it.
+			if schema := param.Schema.Value; schema.Type.Is("string") && schema.Format == "" {
+				s.inferValueType(call, schema)
+				if schema.Type.Is("array") && in == "query" {
+					// `strings.Split` reads a single comma-separated value.
+					param.Explode = openapi3.BoolPtr(false)
+				}
+			}
+			if !param.Required && s.isRequiredValue(call) {
+				param.Required = true
+			}
+			return true
 		}
-		return true
+		return false
 	})
 	return params
 }
 
-// findRequestSchema finds the request schema for a handler.
-func (s *State) findRequestSchema(body *ast.BlockStmt, patterns []config.RequestBodyPattern) types.Type {
+// findRequestSchema finds the request schema for a handler, following calls
+// into project helpers such as `decodeCreateUser(r)`.
+func (s *State) findRequestSchema(body *ast.BlockStmt, patterns []config.RequestBodyPattern, queryPatterns []config.QueryStructPattern) types.Type {
 	var reqType types.Type
-	ast.Inspect(body, func(n ast.Node) bool {
-		call, ok := n.(*ast.CallExpr)
-		if !ok {
-			return true
+	s.inspectCalls(body, &callEnv{}, func(call *ast.CallExpr, env *callEnv) bool {
+		if reqType != nil {
+			return true // Already found; stop descending.
 		}
 
-		info := s.getInfoForNode(call.Fun)
-		if info == nil {
-			return true
+		funcPath := getFuncPath(s.getObjectForExpr(call.Fun))
+		if funcPath == "" {
+			return false
 		}
-
-		obj := s.getObjectForExpr(call.Fun)
-		if obj == nil {
-			return true
-		}
-
-		funcPath := getFuncPath(obj)
 		for _, p := range patterns {
-			if funcPath == p.FunctionPath {
-				if len(call.Args) > p.ArgIndex {
-					arg := call.Args[p.ArgIndex]
-					if info := s.getInfoForNode(arg); info != nil {
-						if tv, ok := info.Types[arg]; ok {
-							if ptr, isPtr := tv.Type.(*types.Pointer); isPtr {
-								reqType = ptr.Elem()
-								return false // Stop searching
-							}
-						}
-					}
+			if funcPath != p.FunctionPath {
+				continue
+			}
+			if len(call.Args) > p.ArgIndex {
+				// The target may be a helper parameter, e.g. `decode(r, &req)`
+				// calling `json.NewDecoder(r.Body).Decode(v)`.
+				// A struct bound from the query string alone, e.g. by echo's
+				// `c.Bind(&filter)`, is documented as query parameters instead.
+				if ptr, isPtr := s.exprType(call.Args[p.ArgIndex], env).(*types.Pointer); isPtr && !bindsOnlyQuery(funcPath, ptr.Elem(), queryPatterns) {
+					reqType = ptr.Elem()
 				}
 			}
+			return true
 		}
-		return true
+		return false
 	})
 	return reqType
 }
 
-// findResponseSchemas finds response schemas for a handler.
-func (s *State) findResponseSchemas(body *ast.BlockStmt, patterns []config.ResponseBodyPattern) map[int]responseInfo {
-	responses := make(map[int]responseInfo)
-	lastStatusCode := 200
-
-	ast.Inspect(body, func(n ast.Node) bool {
-		call, ok := n.(*ast.CallExpr)
+// chainedStatusCode resolves the status code set by a call earlier in the
+// method chain of a response call, e.g. `c.Status(201)` in `c.Status(201).JSON(v)`.
+func (s *State) chainedStatusCode(call *ast.CallExpr, chain *config.StatusCodeCall) (int, bool) {
+	for {
+		sel, ok := call.Fun.(*ast.SelectorExpr)
 		if !ok {
-			return true
-		}
-
-		info := s.getInfoForNode(call.Fun)
-		if info == nil {
-			return true
+			return 0, false
 		}
-
-		obj := s.getObjectForExpr(call.Fun)
-		if obj == nil {
-			return true
+		receiver, ok := sel.X.(*ast.CallExpr)
+		if !ok {
+			return 0, false
 		}
-		funcPath := getFuncPath(obj)
-
-		// Built-in "Magic"
-		if funcPath == "net/http.ResponseWriter.WriteHeader" && len(call.Args) == 1 {
-			if sc, ok := s.resolveIntValue(call.Args[0]); ok {
-				lastStatusCode = sc
-				// If this is a 204, log it immediately as it has no body.
-				if sc == 204 {
-					responses[sc] = responseInfo{Description: "No Content"}
-				}
-			}
-		} else if funcPath == "encoding/json.Encoder.Encode" && len(call.Args) == 1 {
-			if tv, ok := info.Types[call.Args[0]]; ok {
-				responses[lastStatusCode] = responseInfo{Type: tv.Type}
-				lastStatusCode = 200
+		if getFuncPath(s.getObjectForExpr(receiver.Fun)) == chain.FunctionPath {
+			if len(receiver.Args) <= chain.ArgIndex {
+				return 0, false
 			}
+			return s.resolveIntValue(receiver.Args[chain.ArgIndex])
 		}
-
-		// User-configured patterns
-		for _, p := range patterns {
-			if funcPath == p.FunctionPath {
-				statusCode := 200
-				var dataArg ast.Expr
-				var desc string
-
-				if p.StatusCodeIndex != nil && len(call.Args) > *p.StatusCodeIndex {
-					if sc, ok := s.resolveIntValue(call.Args[*p.StatusCodeIndex]); ok {
-						statusCode = sc
-					}
-				}
-
-				if p.DescriptionIndex != nil && len(call.Args) > *p.DescriptionIndex {
-					if d, ok := s.resolveStringValue(call.Args[*p.DescriptionIndex]); ok {
-						desc = d
-					}
-				}
-
-				if len(call.Args) > p.DataIndex {
-					dataArg = call.Args[p.DataIndex]
-				}
-
-				if dataArg != nil {
-					if tv, ok := info.Types[dataArg]; ok {
-						responses[statusCode] = responseInfo{Type: tv.Type, Description: desc}
-					}
-				} else {
-					responses[statusCode] = responseInfo{Description: desc}
-				}
-			}
-		}
-		return true
-	})
-	return responses
+		call = receiver
+	}
 }
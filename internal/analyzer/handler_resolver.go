@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// maxHandlerFactoryDepth bounds how many nested handler factories are followed.
+const maxHandlerFactoryDepth = 5
+
+// resolvedHandler is the function that implements a registered handler.
+type resolvedHandler struct {
+	// Object names the handler. It is the factory for handlers built by a
+	// factory call, the type for http.Handler implementations, and nil for
+	// inline function literals.
+	Object types.Object
+	// Body is the body of the function that handles requests.
+	Body *ast.BlockStmt
+	// Doc is the doc comment of the handler or its factory.
+	Doc *ast.CommentGroup
+}
+
+// resolveHandler finds the function behind a handler expression. Besides
+// references to declared functions, it understands inline function literals,
+// variables holding a literal, `http.HandlerFunc(...)` conversions, factory
+// calls such as `h.List()` that return a closure, and values of project types
+// implementing http.Handler.
+func (s *State) resolveHandler(expr ast.Expr) *resolvedHandler {
+	return s.resolveHandlerDepth(expr, 0)
+}
+
+func (s *State) resolveHandlerDepth(expr ast.Expr, depth int) *resolvedHandler {
+	if depth > maxHandlerFactoryDepth {
+		return nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return s.resolveHandlerDepth(e.X, depth)
+	case *ast.FuncLit:
+		return &resolvedHandler{Body: e.Body}
+	case *ast.CallExpr:
+		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Unwrap" {
+			if _, realHandler := s.parseHandlerChain(sel.X); realHandler != nil {
+				return s.resolveHandlerDepth(realHandler, depth)
+			}
+		}
+		if info := s.getInfoForNode(e.Fun); info != nil && len(e.Args) == 1 {
+			if tv, ok := info.Types[e.Fun]; ok && tv.IsType() {
+				// A conversion such as `http.HandlerFunc(fn)`.
+				return s.resolveHandlerDepth(e.Args[0], depth)
+			}
+		}
+		if handler := s.resolveHandlerFactory(e, depth); handler != nil {
+			return handler
+		}
+	}
+
+	if handler := s.resolveServeHTTP(expr); handler != nil {
+		return handler
+	}
+
+	obj := s.getObjectForExpr(expr)
+	if obj == nil {
+		return nil
+	}
+	if funcDecl, ok := s.Universe.Functions[obj]; ok {
+		return &resolvedHandler{Object: obj, Body: funcDecl.Body, Doc: funcDecl.Doc}
+	}
+	if v, ok := obj.(*types.Var); ok {
+		switch init := s.findVarInitializer(v).(type) {
+		case nil:
+		case *ast.FuncLit:
+			// A variable holding a function literal, e.g. `list := func(w, r) {...}`.
+			return &resolvedHandler{Object: obj, Body: init.Body}
+		default:
+			// e.g. `var h http.Handler = &WebhookHandler{}`.
+			if handler := s.resolveHandlerDepth(init, depth+1); handler != nil && handler.Body != nil {
+				return handler
+			}
+		}
+	}
+	// A handler outside the project is still named, just not analyzed.
+	return &resolvedHandler{Object: obj}
+}
+
+// resolveHandlerFactory resolves a call to a project function that returns a
+// handler, such as `h.List()` returning an http.HandlerFunc closure. The
+// factory names the handler and provides its doc comment.
+func (s *State) resolveHandlerFactory(call *ast.CallExpr, depth int) *resolvedHandler {
+	factoryObj := s.calleeObject(call)
+	if factoryObj == nil {
+		return nil
+	}
+	funcDecl, ok := s.Universe.Functions[factoryObj]
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+
+	var handler *resolvedHandler
+	ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+		if handler != nil {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			// Returns inside nested literals belong to those literals.
+			return false
+		case *ast.ReturnStmt:
+			if len(node.Results) == 1 {
+				handler = s.resolveHandlerDepth(node.Results[0], depth+1)
+			}
+			return false
+		}
+		return true
+	})
+	if handler == nil || handler.Body == nil {
+		return nil
+	}
+	if _, isType := handler.Object.(*types.TypeName); isType {
+		// A constructor returning an http.Handler implementation.
+		return handler
+	}
+
+	// The handler is named and documented after the factory it was registered
+	// with, falling back to the documentation of the returned function.
+	doc := funcDecl.Doc
+	if doc == nil {
+		doc = handler.Doc
+	}
+	return &resolvedHandler{Object: factoryObj, Body: handler.Body, Doc: doc}
+}
+
+// resolveServeHTTP resolves a value of a project type with a ServeHTTP method,
+// such as `&WebhookHandler{}`, to that method. The type names the handler, and
+// its doc comment is used when ServeHTTP has none.
+func (s *State) resolveServeHTTP(expr ast.Expr) *resolvedHandler {
+	info := s.getInfoForNode(expr)
+	if info == nil {
+		return nil
+	}
+	t := info.TypeOf(expr)
+	if t == nil {
+		return nil
+	}
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok {
+		return nil
+	}
+
+	obj, _, _ := types.LookupFieldOrMethod(named, true, nil, "ServeHTTP")
+	method, ok := obj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	funcDecl, ok := s.Universe.Functions[method.Origin()]
+	if !ok || funcDecl.Body == nil {
+		return nil
+	}
+
+	typeName := named.Origin().Obj()
+	doc := funcDecl.Doc
+	if typeDecl, ok := s.Universe.Types[typeName]; ok && doc == nil {
+		doc = typeDecl.Doc
+	}
+	return &resolvedHandler{Object: typeName, Body: funcDecl.Body, Doc: doc}
+}
@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// maxHelperDepth bounds how deep handler analysis follows calls into project
+// helper functions.
+const maxHelperDepth = 3
+
+// callEnv is the context a function body is analyzed in. For a helper called
+// from a handler, it binds the helper's parameters to the caller's arguments
+// so that values such as status codes and response types can be traced back
+// to the call site.
+type callEnv struct {
+	// fn is the helper being analyzed, or nil for the handler itself.
+	fn *ast.FuncDecl
+	// params maps the helper's parameters to the caller's argument expressions.
+	params map[types.Object]ast.Expr
+	// parent is the environment the argument expressions are evaluated in.
+	parent *callEnv
+	depth  int
+}
+
+// binding returns the caller's argument expression bound to an identifier
+// that refers to a parameter of the analyzed helper, and the environment it
+// must be evaluated in.
+func (s *State) binding(expr ast.Expr, env *callEnv) (ast.Expr, *callEnv, bool) {
+	if env == nil || env.params == nil {
+		return nil, nil, false
+	}
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return nil, nil, false
+	}
+	arg, ok := env.params[s.getObjectForExpr(ident)]
+	if !ok {
+		return nil, nil, false
+	}
+	return arg, env.parent, true
+}
+
+// exprType returns the type of an expression, following helper parameters
+// back to the caller's arguments. A helper taking `v any` thereby reports the
+// type of the value its caller passed.
+func (s *State) exprType(expr ast.Expr, env *callEnv) types.Type {
+	if arg, argEnv, ok := s.binding(expr, env); ok {
+		return s.exprType(arg, argEnv)
+	}
+	if unary, ok := ast.Unparen(expr).(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		if _, _, isParam := s.binding(unary.X, env); isParam {
+			if elem := s.exprType(unary.X, env); elem != nil {
+				return types.NewPointer(elem)
+			}
+		}
+	}
+
+	info := s.getInfoForNode(expr)
+	if info == nil {
+		return nil
+	}
+	if tv, ok := info.Types[expr]; ok {
+		return tv.Type
+	}
+	return nil
+}
+
+// resolveIntValueInEnv resolves an integer like resolveIntValue, following
+// helper parameters back to the caller's arguments.
+func (s *State) resolveIntValueInEnv(expr ast.Expr, env *callEnv) (int, bool) {
+	if arg, argEnv, ok := s.binding(expr, env); ok {
+		return s.resolveIntValueInEnv(arg, argEnv)
+	}
+	return s.resolveIntValue(expr)
+}
+
+// resolveStringValueInEnv resolves a string like resolveStringValue,
+// following helper parameters back to the caller's arguments.
+func (s *State) resolveStringValueInEnv(expr ast.Expr, env *callEnv) (string, bool) {
+	if arg, argEnv, ok := s.binding(expr, env); ok {
+		return s.resolveStringValueInEnv(arg, argEnv)
+	}
+	return s.resolveStringValue(expr)
+}
+
+// inspectCalls calls visit for every call in body. Calls that visit does not
+// recognize are followed into the project functions they invoke, up to
+// maxHelperDepth, with the helper's parameters bound to the call's arguments.
+func (s *State) inspectCalls(body *ast.BlockStmt, env *callEnv, visit func(call *ast.CallExpr, env *callEnv) bool) {
+	if body == nil {
+		return
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if !visit(call, env) {
+			s.inspectHelper(call, env, visit)
+		}
+		return true
+	})
+}
+
+// inspectHelper descends into the project function invoked by call.
+func (s *State) inspectHelper(call *ast.CallExpr, env *callEnv, visit func(call *ast.CallExpr, env *callEnv) bool) {
+	if helperEnv := s.helperEnv(call, env); helperEnv != nil {
+		s.inspectCalls(helperEnv.fn.Body, helperEnv, visit)
+	}
+}
+
+// helperEnv returns the environment for analyzing the project function
+// invoked by call, or nil if it is not a project function, is already being
+// analyzed, or lies beyond maxHelperDepth.
+func (s *State) helperEnv(call *ast.CallExpr, env *callEnv) *callEnv {
+	if env.depth >= maxHelperDepth {
+		return nil
+	}
+	funcDecl := s.funcDeclForCall(call)
+	if funcDecl == nil || funcDecl.Body == nil {
+		return nil
+	}
+	for e := env; e != nil; e = e.parent {
+		if e.fn == funcDecl {
+			return nil // Recursion.
+		}
+	}
+
+	helperEnv := &callEnv{
+		fn:     funcDecl,
+		params: make(map[types.Object]ast.Expr),
+		parent: env,
+		depth:  env.depth + 1,
+	}
+	for i, arg := range call.Args {
+		if param := s.paramVar(funcDecl.Type, i); param != nil {
+			helperEnv.params[param] = arg
+		}
+	}
+	return helperEnv
+}
@@ -0,0 +1,29 @@
+package analyzer
+
+import "testing"
+
+func TestHelpersBindCallerArguments(t *testing.T) {
+	s := loadFixture(t, "responses", nil)
+	body := funcBody(t, s, "RegisterUser")
+	patterns := s.Config.HandlerPatterns
+
+	request := s.findRequestSchema(body, patterns.RequestBody, patterns.QueryStruct)
+	if request == nil || request.String() != "example.com/responses.CreateUserRequest" {
+		t.Errorf("request type = %v, want example.com/responses.CreateUserRequest", request)
+	}
+
+	responses := s.findResponseSchemas(body, patterns.ResponseBody)
+	want := map[int]string{
+		400: "example.com/responses.APIError",
+		201: "example.com/responses.User",
+	}
+	if len(responses) != len(want) {
+		t.Errorf("responses = %v, want statuses of %v", responses, want)
+	}
+	for status, typeName := range want {
+		infos := responses[status]
+		if len(infos) != 1 || infos[0].Type == nil || infos[0].Type.String() != typeName {
+			t.Errorf("responses[%d] = %+v, want one %s", status, infos, typeName)
+		}
+	}
+}
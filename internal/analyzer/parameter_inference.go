@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// inferValueType refines a parameter schema from the conversions its value
+// flows into, e.g. `strconv.Atoi(r.PathValue("id"))`, or `limit := q.Get("limit")`
+// followed by `strconv.Atoi(limit)`. It reports whether a conversion was found.
+func (s *State) inferValueType(access ast.Expr, schema *openapi3.Schema) bool {
+	for _, use := range s.valueUses(access) {
+		if len(use) < 2 {
+			continue
+		}
+		if call, ok := use[1].(*ast.CallExpr); ok && s.applyConversionType(call, schema) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRequiredValue reports whether a handler rejects a request without the
+// parameter, as in `if v == "" { http.Error(w, "missing v", 400); return }`.
+func (s *State) isRequiredValue(access ast.Expr) bool {
+	for _, use := range s.valueUses(access) {
+		if len(use) < 2 {
+			continue
+		}
+		cmp, ok := use[1].(*ast.BinaryExpr)
+		if !ok || cmp.Op != token.EQL {
+			continue
+		}
+		other := cmp.X
+		if cmp.X == use[0] {
+			other = cmp.Y
+		}
+		if v, ok := s.resolveStringValue(other); !ok || v != "" {
+			continue
+		}
+
+		// Climb through `||` and parentheses to the if statement.
+		var child ast.Node = cmp
+	climb:
+		for _, n := range use[2:] {
+			switch n := n.(type) {
+			case *ast.ParenExpr:
+				child = n
+			case *ast.BinaryExpr:
+				if n.Op != token.LOR {
+					break climb
+				}
+				child = n
+			case *ast.IfStmt:
+				if n.Cond == child && s.findResponseSchemas(n.Body, s.Config.HandlerPatterns.ResponseBody)[http.StatusBadRequest] != nil {
+					return true
+				}
+				break climb
+			default:
+				break climb
+			}
+		}
+	}
+	return false
+}
+
+// valueUses returns the expressions a parameter value is read through: the
+// access itself or, when it is assigned to a variable, every use of that
+// variable. Each use is returned with its enclosing path, innermost first.
+func (s *State) valueUses(access ast.Expr) [][]ast.Node {
+	path, ok := s.findPathToNode(access)
+	if !ok || len(path) < 2 {
+		return nil
+	}
+
+	var obj types.Object
+	switch parent := path[1].(type) {
+	case *ast.AssignStmt:
+		if len(parent.Rhs) == 1 && parent.Rhs[0] == access {
+			if ident, ok := parent.Lhs[0].(*ast.Ident); ok {
+				obj = s.getObjectForExpr(ident)
+			}
+		}
+	case *ast.ValueSpec:
+		if len(parent.Values) == 1 && parent.Values[0] == access {
+			obj = s.getObjectForExpr(parent.Names[0])
+		}
+	}
+	if obj == nil {
+		return [][]ast.Node{path}
+	}
+
+	body := enclosingFuncBody(path)
+	if body == nil {
+		return nil
+	}
+	var uses [][]ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if info := s.getInfoForNode(ident); info == nil || info.Uses[ident] != obj {
+			return true
+		}
+		if usePath, ok := s.findPathToNode(ident); ok {
+			uses = append(uses, usePath)
+		}
+		return true
+	})
+	return uses
+}
+
+// enclosingFuncBody returns the body of the innermost function on a path.
+func enclosingFuncBody(path []ast.Node) *ast.BlockStmt {
+	for _, n := range path {
+		switch fn := n.(type) {
+		case *ast.FuncLit:
+			return fn.Body
+		case *ast.FuncDecl:
+			return fn.Body
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package analyzer
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Zachacious/go-respec/internal/config"
+)
+
+// anyMethodVerbs are the HTTP methods an operation is generated for when a
+// registration matches every method (e.g. a ServeMux pattern without a method).
+// It is used when the router definition does not configure AnyMethodVerbs.
+var anyMethodVerbs = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// anyMethodExtension marks operations generated from an any-method registration.
+const anyMethodExtension = "x-respec-any-method"
+
+// serveMuxWildcardRegex matches the `{name...}` and `{$}` forms of ServeMux wildcards.
+var serveMuxWildcardRegex = regexp.MustCompile(`\{(\w*)(\.\.\.)?\}|\{\$\}`)
+
+// colonParamRegex matches `:name` parameters (optionally suffixed with `?`)
+// and `*name`, bare `*` or `+` wildcards of colon path syntax. Optional
+// parameters are expanded by expandOptionalParams first.
+var colonParamRegex = regexp.MustCompile(`(^|/)([:*+])(\w*)\??`)
+
+// serveMuxPattern holds the parts of a net/http ServeMux pattern of the form
+// "[METHOD ][HOST]/[PATH]".
+type serveMuxPattern struct {
+	Method string
+	Host   string
+	Path   string
+}
+
+// parseServeMuxPattern splits a ServeMux pattern into its method, host and path.
+func parseServeMuxPattern(pattern string) serveMuxPattern {
+	var p serveMuxPattern
+	rest := strings.TrimSpace(pattern)
+	if method, after, found := strings.Cut(rest, " "); found {
+		p.Method = strings.ToUpper(method)
+		rest = strings.TrimLeft(after, " \t")
+	}
+	if i := strings.Index(rest, "/"); i > 0 {
+		p.Host = rest[:i]
+		rest = rest[i:]
+	}
+	p.Path = rest
+	return p
+}
+
+// normalizePath rewrites a router-specific path pattern into an OpenAPI path
+// template. It also returns the regular expressions that constrain path
+// variables of the form `{name:pattern}`, keyed by variable name, as anchored
+// patterns suitable for a parameter schema, and the tokens handlers read
+// renamed wildcards by, keyed by the emitted name (e.g. "*" for the
+// `{wildcard}` of chi's `/*`, read with `chi.URLParam(r, "*")`).
+func normalizePath(syntax, path string) (string, map[string]string, map[string]string) {
+	switch syntax {
+	case config.PathSyntaxServeMux:
+		return serveMuxWildcardRegex.ReplaceAllStringFunc(path, func(wildcard string) string {
+			if wildcard == "{$}" {
+				// `{$}` only anchors the match at a trailing slash.
+				return ""
+			}
+			return "{" + strings.TrimSuffix(strings.Trim(wildcard, "{}"), "...") + "}"
+		}), nil, nil
+	case config.PathSyntaxColon:
+		// Unnamed wildcards are numbered like fiber's `*1`, `*2` and `+1` when a
+		// path has more than one of a kind, so their names stay unique.
+		counts := make(map[string]int)
+		for _, m := range colonParamRegex.FindAllStringSubmatch(path, -1) {
+			if m[3] == "" {
+				counts[m[2]]++
+			}
+		}
+		seen := make(map[string]int)
+		var wildcards map[string]string
+		normalized := colonParamRegex.ReplaceAllStringFunc(path, func(segment string) string {
+			m := colonParamRegex.FindStringSubmatch(segment)
+			name := m[3]
+			if name == "" {
+				// An unnamed wildcard such as echo's `/static/*` or fiber's `/files/+`.
+				switch m[2] {
+				case "*":
+					name = "wildcard"
+				case "+":
+					name = "plus"
+				default:
+					return segment
+				}
+				token := m[2]
+				if counts[m[2]] > 1 {
+					seen[m[2]]++
+					name += strconv.Itoa(seen[m[2]])
+					token += strconv.Itoa(seen[m[2]])
+				}
+				if wildcards == nil {
+					wildcards = make(map[string]string)
+				}
+				wildcards[name] = token
+			}
+			return m[1] + "{" + name + "}"
+		})
+		return normalized, nil, wildcards
+	}
+	// chi's catch-all `/*` matches the rest of the path.
+	var wildcards map[string]string
+	if rest, ok := strings.CutSuffix(path, "/*"); ok {
+		path = rest + "/{wildcard}"
+		wildcards = map[string]string{"wildcard": "*"}
+	}
+	normalized, patterns := extractVariablePatterns(path)
+	return normalized, patterns, wildcards
+}
+
+// expandOptionalParams returns the paths matched by a colon syntax path with
+// optional parameters, e.g. "/users" and "/users/:id" for fiber's
+// "/users/:id?". A path without optional parameters is returned as it is.
+func expandOptionalParams(path string) []string {
+	segments := strings.Split(path, "/")
+	paths := []string{segments[0]}
+	for _, segment := range segments[1:] {
+		name, optional := strings.CutSuffix(segment, "?")
+		n := len(paths)
+		for i := 0; i < n; i++ {
+			if optional && strings.HasPrefix(name, ":") {
+				paths = append(paths, paths[i]+"/"+name)
+			} else {
+				paths[i] += "/" + segment
+			}
+		}
+	}
+	for i, p := range paths {
+		if p == "" {
+			paths[i] = "/"
+		}
+	}
+	return paths
+}
+
+// extractVariablePatterns removes regular expressions from path variables of
+// the form `{name:pattern}` (chi, gorilla/mux) and returns them by variable
+// name. Patterns may contain braces of their own, e.g. `{id:[0-9]{3}}`.
+func extractVariablePatterns(path string) (string, map[string]string) {
+	var b, name, pattern strings.Builder
+	var patterns map[string]string
+	depth := 0
+	inPattern := false
+	for _, r := range path {
+		switch {
+		case r == '{':
+			depth++
+			if depth == 1 {
+				name.Reset()
+				pattern.Reset()
+				b.WriteRune(r)
+				continue
+			}
+		case r == '}':
+			depth--
+			if depth == 0 {
+				if inPattern && pattern.Len() > 0 {
+					if patterns == nil {
+						patterns = make(map[string]string)
+					}
+					patterns[name.String()] = anchorPattern(pattern.String())
+				}
+				inPattern = false
+				b.WriteRune(r)
+				continue
+			}
+		case r == ':' && depth == 1 && !inPattern:
+			inPattern = true
+			continue
+		}
+		switch {
+		case inPattern:
+			pattern.WriteRune(r)
+		case depth == 1:
+			name.WriteRune(r)
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), patterns
+}
+
+// anchorPattern anchors a router regular expression, which must match the
+// whole path segment, for use as an OpenAPI schema pattern.
+func anchorPattern(pattern string) string {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "^"), "$")
+	if hasTopLevelAlternation(pattern) {
+		pattern = "(?:" + pattern + ")"
+	}
+	return "^" + pattern + "$"
+}
+
+// hasTopLevelAlternation reports whether a regular expression has a `|`
+// outside of groups and character classes, which anchors would bind to only
+// the first and last alternative.
+func hasTopLevelAlternation(pattern string) bool {
+	depth := 0
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+		case inClass:
+			inClass = c != ']'
+		case c == '[':
+			inClass = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '|' && depth == 0:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Zachacious/go-respec/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestParseServeMuxPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    serveMuxPattern
+	}{
+		{"/users/{id}", serveMuxPattern{Path: "/users/{id}"}},
+		{"GET /users/{id}", serveMuxPattern{Method: "GET", Path: "/users/{id}"}},
+		{"post  /users", serveMuxPattern{Method: "POST", Path: "/users"}},
+		{"example.com/users", serveMuxPattern{Host: "example.com", Path: "/users"}},
+		{"GET example.com/files/{path...}", serveMuxPattern{Method: "GET", Host: "example.com", Path: "/files/{path...}"}},
+	}
+	for _, tt := range tests {
+		if got := parseServeMuxPattern(tt.pattern); got != tt.want {
+			t.Errorf("parseServeMuxPattern(%q) = %+v, want %+v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		syntax        string
+		path          string
+		want          string
+		wantPatterns  map[string]string
+		wantWildcards map[string]string
+	}{
+		{config.PathSyntaxServeMux, "/users/{id}", "/users/{id}", nil, nil},
+		{config.PathSyntaxServeMux, "/files/{path...}", "/files/{path}", nil, nil},
+		{config.PathSyntaxServeMux, "/users/{$}", "/users/", nil, nil},
+		{config.PathSyntaxColon, "/users/:id", "/users/{id}", nil, nil},
+		{config.PathSyntaxColon, "/users/:id/posts/:postID", "/users/{id}/posts/{postID}", nil, nil},
+		{config.PathSyntaxColon, "/files/*filepath", "/files/{filepath}", nil, nil},
+		{config.PathSyntaxColon, "/static/*", "/static/{wildcard}", nil, map[string]string{"wildcard": "*"}},
+		{config.PathSyntaxColon, "/files/+", "/files/{plus}", nil, map[string]string{"plus": "+"}},
+		{config.PathSyntaxColon, "/*/to/*", "/{wildcard1}/to/{wildcard2}", nil, map[string]string{"wildcard1": "*1", "wildcard2": "*2"}},
+		{"", "/users/{id}", "/users/{id}", nil, nil},
+		{"", "/users/{id:[0-9]+}", "/users/{id}", map[string]string{"id": "^[0-9]+$"}, nil},
+		{"", "/static/*", "/static/{wildcard}", nil, map[string]string{"wildcard": "*"}},
+		{"", "/codes/{code:[A-Z]{3}}/*", "/codes/{code}/{wildcard}", map[string]string{"code": "^[A-Z]{3}$"}, map[string]string{"wildcard": "*"}},
+	}
+	for _, tt := range tests {
+		got, patterns, wildcards := normalizePath(tt.syntax, tt.path)
+		if got != tt.want {
+			t.Errorf("normalizePath(%q, %q) = %q, want %q", tt.syntax, tt.path, got, tt.want)
+		}
+		if !maps.Equal(patterns, tt.wantPatterns) {
+			t.Errorf("normalizePath(%q, %q) patterns = %v, want %v", tt.syntax, tt.path, patterns, tt.wantPatterns)
+		}
+		if !maps.Equal(wildcards, tt.wantWildcards) {
+			t.Errorf("normalizePath(%q, %q) wildcards = %v, want %v", tt.syntax, tt.path, wildcards, tt.wantWildcards)
+		}
+	}
+}
+
+func TestExpandOptionalParams(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/users", []string{"/users"}},
+		{"/users/:id", []string{"/users/:id"}},
+		{"/users/:id?", []string{"/users", "/users/:id"}},
+		{"/:id?", []string{"/", "/:id"}},
+		{"/a/:x?/b/:y?", []string{"/a/b", "/a/:x/b", "/a/b/:y", "/a/:x/b/:y"}},
+	}
+	for _, tt := range tests {
+		if got := expandOptionalParams(tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("expandOptionalParams(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractVariablePatterns(t *testing.T) {
+	tests := []struct {
+		path         string
+		want         string
+		wantPatterns map[string]string
+	}{
+		{"/users/{id}", "/users/{id}", nil},
+		{"/users/{id:[0-9]+}", "/users/{id}", map[string]string{"id": "^[0-9]+$"}},
+		{"/codes/{code:[a-z]{2,3}}", "/codes/{code}", map[string]string{"code": "^[a-z]{2,3}$"}},
+		{"/{kind:^(?:a|b)$}/{id:\\d+}", "/{kind}/{id}", map[string]string{"kind": "^(?:a|b)$", "id": "^\\d+$"}},
+		{"/{name:}", "/{name}", nil},
+	}
+	for _, tt := range tests {
+		got, patterns := extractVariablePatterns(tt.path)
+		if got != tt.want || !maps.Equal(patterns, tt.wantPatterns) {
+			t.Errorf("extractVariablePatterns(%q) = %q, %v, want %q, %v", tt.path, got, patterns, tt.want, tt.wantPatterns)
+		}
+	}
+}
+
+func TestAnchorPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"[0-9]+", "^[0-9]+$"},
+		{"^[0-9]+$", "^[0-9]+$"},
+		{"a|b", "^(?:a|b)$"},
+		{"^a|b$", "^(?:a|b)$"},
+		{"(?:a|b)", "^(?:a|b)$"},
+		{"[|]+", "^[|]+$"},
+		{`\|x`, `^\|x$`},
+	}
+	for _, tt := range tests {
+		if got := anchorPattern(tt.pattern); got != tt.want {
+			t.Errorf("anchorPattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestInferPathParameterTypeOfWildcard(t *testing.T) {
+	s := loadFixture(t, "paths", func(cfg *config.Config) {
+		cfg.HandlerPatterns.PathParameter = append(cfg.HandlerPatterns.PathParameter, config.ParameterPattern{
+			FunctionPath: "example.com/paths.URLParam",
+			NameIndex:    1,
+		})
+	})
+
+	_, _, wildcards := normalizePath("", "/pages/*")
+	param := openapi3.NewPathParameter("wildcard").WithSchema(openapi3.NewStringSchema())
+	s.inferPathParameterType(funcBody(t, s, "GetPage"), param, wildcards["wildcard"])
+	if !param.Schema.Value.Type.Is("integer") {
+		t.Errorf("type = %v, want integer", param.Schema.Value.Type)
+	}
+}
@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"sort"
+
+	"github.com/Zachacious/go-respec/internal/config"
+)
+
+// helperSummary records how a function's parameters flow into the request and
+// response sinks it reaches. Indexes are parameter indexes, -1 if unset.
+type helperSummary struct {
+	requestArg  int
+	dataArg     int
+	statusArg   int
+	descArg     int
+	writes      bool
+	unsupported bool
+}
+
+// synthesizePatterns summarizes every project function and derives request
+// and response body patterns for helpers whose parameters flow into known
+// sinks, e.g. a `writeJSON(w, status, v)` whose `v` reaches
+// `json.Encoder.Encode` and whose `status` reaches `WriteHeader`. Functions
+// already covered by a configured pattern are skipped.
+func (s *State) synthesizePatterns() *config.HandlerPatternsConfig {
+	patterns := s.Config.HandlerPatterns
+	known := make(map[string]bool)
+	for _, p := range patterns.RequestBody {
+		known[p.FunctionPath] = true
+	}
+	for _, p := range patterns.ResponseBody {
+		known[p.FunctionPath] = true
+	}
+
+	synthesized := &config.HandlerPatternsConfig{}
+	for obj, funcDecl := range s.Universe.Functions {
+		funcPath := getFuncPath(obj)
+		if funcPath == "" || known[funcPath] || funcDecl.Body == nil || funcDecl.Type.Params.NumFields() == 0 {
+			continue
+		}
+
+		summary := s.summarizeHelper(funcDecl)
+		if summary.requestArg >= 0 {
+			synthesized.RequestBody = append(synthesized.RequestBody, config.RequestBodyPattern{
+				FunctionPath: funcPath,
+				ArgIndex:     summary.requestArg,
+			})
+		}
+		if summary.writes && !summary.unsupported && (summary.dataArg >= 0 || summary.statusArg >= 0) {
+			p := config.ResponseBodyPattern{FunctionPath: funcPath, DataIndex: summary.dataArg}
+			if summary.statusArg >= 0 {
+				p.StatusCodeIndex = intPtr(summary.statusArg)
+			}
+			if summary.descArg >= 0 {
+				p.DescriptionIndex = intPtr(summary.descArg)
+			}
+			synthesized.ResponseBody = append(synthesized.ResponseBody, p)
+		}
+	}
+
+	// Map iteration order is random; keep the output stable.
+	sort.Slice(synthesized.RequestBody, func(i, j int) bool {
+		return synthesized.RequestBody[i].FunctionPath < synthesized.RequestBody[j].FunctionPath
+	})
+	sort.Slice(synthesized.ResponseBody, func(i, j int) bool {
+		return synthesized.ResponseBody[i].FunctionPath < synthesized.ResponseBody[j].FunctionPath
+	})
+	return synthesized
+}
+
+// summarizeHelper traces the sinks reached from a function, including through
+// the helpers it calls, back to the function's own parameters. A response
+// pattern can only describe values passed in by the caller, so a summary is
+// marked unsupported if any response data or status comes from elsewhere.
+func (s *State) summarizeHelper(funcDecl *ast.FuncDecl) helperSummary {
+	summary := helperSummary{requestArg: -1, dataArg: -1, statusArg: -1, descArg: -1}
+	patterns := s.Config.HandlerPatterns
+
+	// record merges a parameter index into a summary field. Different
+	// parameters reaching the same sink cannot be described by one pattern.
+	record := func(field *int, index int) {
+		switch {
+		case index < 0:
+			summary.unsupported = true
+		case *field < 0:
+			*field = index
+		case *field != index:
+			summary.unsupported = true
+		}
+	}
+
+	root := &callEnv{fn: funcDecl}
+	s.inspectCalls(funcDecl.Body, root, func(call *ast.CallExpr, env *callEnv) bool {
+		funcPath := getFuncPath(s.getObjectForExpr(call.Fun))
+		if funcPath == "" {
+			return false
+		}
+
+		switch {
+		case funcPath == "net/http.ResponseWriter.WriteHeader" && len(call.Args) == 1:
+			summary.writes = true
+			record(&summary.statusArg, s.paramIndexOf(call.Args[0], env, funcDecl))
+			return true
+		case funcPath == "encoding/json.Encoder.Encode" && len(call.Args) == 1:
+			summary.writes = true
+			record(&summary.dataArg, s.paramIndexOf(call.Args[0], env, funcDecl))
+			return true
+		}
+
+		for _, p := range patterns.RequestBody {
+			if funcPath == p.FunctionPath && len(call.Args) > p.ArgIndex {
+				if index := s.paramIndexOf(call.Args[p.ArgIndex], env, funcDecl); index >= 0 && summary.requestArg < 0 {
+					summary.requestArg = index
+				}
+				return true
+			}
+		}
+
+		for _, p := range patterns.ResponseBody {
+			if funcPath != p.FunctionPath {
+				continue
+			}
+			summary.writes = true
+			if p.DataIndex >= 0 && len(call.Args) > p.DataIndex {
+				record(&summary.dataArg, s.paramIndexOf(call.Args[p.DataIndex], env, funcDecl))
+			}
+			if p.StatusCodeIndex != nil && len(call.Args) > *p.StatusCodeIndex {
+				record(&summary.statusArg, s.paramIndexOf(call.Args[*p.StatusCodeIndex], env, funcDecl))
+			} else if p.StatusCodeChain != nil {
+				// A chained status cannot be passed through a helper's parameters.
+				summary.unsupported = true
+			}
+			if p.DescriptionIndex != nil && len(call.Args) > *p.DescriptionIndex {
+				if index := s.paramIndexOf(call.Args[*p.DescriptionIndex], env, funcDecl); index >= 0 {
+					summary.descArg = index
+				}
+			}
+			return true
+		}
+		return false
+	})
+	return summary
+}
+
+// paramIndexOf follows an expression back through helper parameter bindings
+// and returns the index of the parameter of fn it refers to, or -1.
+func (s *State) paramIndexOf(expr ast.Expr, env *callEnv, fn *ast.FuncDecl) int {
+	for {
+		arg, argEnv, ok := s.binding(expr, env)
+		if !ok {
+			break
+		}
+		expr, env = arg, argEnv
+	}
+
+	ident, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return -1
+	}
+	obj, ok := s.getObjectForExpr(ident).(*types.Var)
+	if !ok {
+		return -1
+	}
+
+	index := 0
+	for _, field := range fn.Type.Params.List {
+		if len(field.Names) == 0 {
+			index++
+			continue
+		}
+		for _, name := range field.Names {
+			if s.getObjectForExpr(name) == obj {
+				return index
+			}
+			index++
+		}
+	}
+	return -1
+}
+
+// intPtr returns a pointer to i.
+func intPtr(i int) *int { return &i }
@@ -0,0 +1,220 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Zachacious/go-respec/internal/config"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// defaultQueryTags are the struct tags naming query parameters when a
+// query-struct pattern does not configure its own.
+var defaultQueryTags = []string{"form", "query", "schema"}
+
+// findQueryStructParameters finds query parameters bound into a struct by a
+// query-struct pattern, e.g. `c.ShouldBindQuery(&filter)`. Each exported field
+// of the struct becomes a typed query parameter.
+func (s *State) findQueryStructParameters(body *ast.BlockStmt, patterns []config.QueryStructPattern, exclusions map[string]bool) []*openapi3.ParameterRef {
+	var params []*openapi3.ParameterRef
+	foundParams := make(map[string]bool)
+
+	s.inspectCalls(body, &callEnv{}, func(call *ast.CallExpr, env *callEnv) bool {
+		funcPath := getFuncPath(s.getObjectForExpr(call.Fun))
+		if funcPath == "" {
+			return false
+		}
+		for _, p := range patterns {
+			if funcPath != p.FunctionPath {
+				continue
+			}
+			if len(call.Args) <= p.ArgIndex {
+				return true
+			}
+			ptr, isPtr := s.exprType(call.Args[p.ArgIndex], env).(*types.Pointer)
+			if !isPtr {
+				return true
+			}
+			st, isStruct := ptr.Elem().Underlying().(*types.Struct)
+			if !isStruct {
+				return true
+			}
+			for _, param := range s.queryStructParameters(st, p) {
+				if foundParams[param.Name] || (exclusions != nil && exclusions[param.Name]) {
+					continue
+				}
+				params = append(params, &openapi3.ParameterRef{Value: param})
+				foundParams[param.Name] = true
+			}
+			return true
+		}
+		return false
+	})
+	return params
+}
+
+// queryStructParameters returns the query parameters of a bound struct.
+// Embedded structs without a tag are flattened into their parent, as the
+// binders do.
+func (s *State) queryStructParameters(st *types.Struct, p config.QueryStructPattern) []*openapi3.Parameter {
+	tagKeys := p.Tags
+	if len(tagKeys) == 0 {
+		tagKeys = defaultQueryTags
+	}
+
+	var params []*openapi3.Parameter
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i))
+
+		var name, options string
+		tagged := false
+		for _, key := range tagKeys {
+			if value, ok := tag.Lookup(key); ok {
+				name, options, _ = strings.Cut(value, ",")
+				tagged = true
+				break
+			}
+		}
+		if !field.Exported() || name == "-" {
+			continue
+		}
+		if field.Embedded() && name == "" {
+			fieldType := field.Type()
+			if ptr, ok := fieldType.(*types.Pointer); ok {
+				fieldType = ptr.Elem()
+			}
+			if embedded, ok := fieldType.Underlying().(*types.Struct); ok {
+				params = append(params, s.queryStructParameters(embedded, p)...)
+				continue
+			}
+		}
+		if !tagged && p.TaggedOnly {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+
+		param := openapi3.NewQueryParameter(name)
+		param.Schema = constrainedSchema(s.queryFieldSchema(field.Type()), tag)
+		if doc, ok := s.SchemaGen.docs[field]; ok {
+			param.Description = doc
+			param.Deprecated = isDeprecated(doc)
+		}
+		param.Required = isRequiredByTag(tag)
+
+		// The default comes from a `default` tag or gin's `form:"name,default=value"`.
+		defaultValue, hasDefault := tag.Lookup("default")
+		for _, option := range strings.Split(options, ",") {
+			if value, ok := strings.CutPrefix(option, "default="); ok {
+				defaultValue, hasDefault = value, true
+			}
+		}
+		if hasDefault && param.Schema.Value != nil {
+			if value, ok := parseDefaultValue(param.Schema.Value, defaultValue); ok {
+				param.Schema.Value.Default = value
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// bindsOnlyQuery reports whether a call to funcPath binds a struct from the
+// query string alone: the function is a query-struct pattern and every field
+// of the struct carries one of the pattern's tags, as in an echo filter struct
+// with only `query` tags. Such a struct is not also a request body.
+func bindsOnlyQuery(funcPath string, t types.Type, patterns []config.QueryStructPattern) bool {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for _, p := range patterns {
+		if p.FunctionPath == funcPath && queryTagged(st, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryTagged reports whether every exported field of a struct, including
+// the fields of untagged embedded structs, carries one of a pattern's tags.
+func queryTagged(st *types.Struct, p config.QueryStructPattern) bool {
+	tagKeys := p.Tags
+	if len(tagKeys) == 0 {
+		tagKeys = defaultQueryTags
+	}
+
+	found := false
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Exported() {
+			continue
+		}
+		tag := reflect.StructTag(st.Tag(i))
+		if slices.ContainsFunc(tagKeys, func(key string) bool { _, ok := tag.Lookup(key); return ok }) {
+			found = true
+			continue
+		}
+		fieldType := field.Type()
+		if ptr, ok := fieldType.(*types.Pointer); ok {
+			fieldType = ptr.Elem()
+		}
+		embedded, ok := fieldType.Underlying().(*types.Struct)
+		if !field.Embedded() || !ok || !queryTagged(embedded, p) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// queryFieldSchema returns an inline schema for a bound query field. The
+// schema is a copy, so setting a default does not alter the shared schema of
+// the field's type.
+func (s *State) queryFieldSchema(t types.Type) *openapi3.SchemaRef {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time" {
+		return openapi3.NewDateTimeSchema().NewRef()
+	}
+
+	ref := s.SchemaGen.GenerateSchema(t)
+	if ref.Ref != "" || ref.Value == nil {
+		return ref
+	}
+	schema := *ref.Value
+	return schema.NewRef()
+}
+
+// hasTagOption reports whether a comma-separated tag value lists an option,
+// e.g. "omitempty" in `json:"name,omitempty"`.
+func hasTagOption(value, option string) bool {
+	return slices.Contains(strings.Split(value, ","), option)
+}
+
+// parseDefaultValue converts a value from a struct tag, such as a default or
+// a `oneof` value, to the type of a schema.
+func parseDefaultValue(schema *openapi3.Schema, value string) (any, bool) {
+	switch {
+	case schema.Type.Is("integer"):
+		n, err := strconv.ParseInt(value, 10, 64)
+		return n, err == nil
+	case schema.Type.Is("number"):
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	case schema.Type.Is("boolean"):
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	case schema.Type.Is("string"):
+		return value, true
+	}
+	return nil, false
+}
@@ -0,0 +1,296 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// expandRangeArgs unrolls table-driven registrations such as
+//
+//	for _, rt := range routes { r.Method(rt.Method, rt.Path, rt.Handler) }
+//
+// where `routes` is a composite literal slice, array or map. It returns one
+// argument list per element, with references to the loop variables replaced
+// by the element's expressions. ok is false if the call's arguments do not
+// depend on an enclosing range loop over a resolvable literal.
+func (s *State) expandRangeArgs(call *ast.CallExpr) (expansions [][]ast.Expr, ok bool) {
+	rangeStmt := s.enclosingRangeFor(call)
+	if rangeStmt == nil {
+		return nil, false
+	}
+	keyObj, valueObj := s.rangeVarObjects(rangeStmt)
+
+	lit := s.resolveCompositeLit(rangeStmt.X, 0)
+	if lit == nil {
+		return nil, false
+	}
+	info := s.getInfoForNode(lit)
+	if info == nil {
+		return nil, false
+	}
+	_, isMap := info.TypeOf(lit).Underlying().(*types.Map)
+
+	for _, elt := range lit.Elts {
+		bindings := make(map[types.Object]ast.Expr)
+		valueExpr := elt
+		if kv, isKV := elt.(*ast.KeyValueExpr); isKV {
+			valueExpr = kv.Value
+			if isMap && keyObj != nil {
+				bindings[keyObj] = kv.Key
+			}
+		}
+		if valueObj != nil {
+			bindings[valueObj] = valueExpr
+		}
+
+		args := make([]ast.Expr, len(call.Args))
+		complete := true
+		for i, arg := range call.Args {
+			if args[i] = s.substituteRangeExpr(arg, bindings); args[i] == nil {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			expansions = append(expansions, args)
+		} else {
+			fmt.Fprintf(s.log, "  [Warning] Skipping the route registered at %s for the element at %s: its loop variables cannot be resolved.\n",
+				s.Fset.Position(call.Pos()), s.Fset.Position(elt.Pos()))
+		}
+	}
+	return expansions, true
+}
+
+// enclosingRangeFor returns the innermost range statement whose loop variables
+// are referenced by the call's arguments.
+func (s *State) enclosingRangeFor(call *ast.CallExpr) *ast.RangeStmt {
+	path, found := s.findPathToNode(call)
+	if !found {
+		return nil
+	}
+	for _, node := range path[1:] {
+		rangeStmt, ok := node.(*ast.RangeStmt)
+		if !ok {
+			continue
+		}
+		keyObj, valueObj := s.rangeVarObjects(rangeStmt)
+		loopVars := make(map[types.Object]bool)
+		for _, obj := range []types.Object{keyObj, valueObj} {
+			if obj != nil {
+				loopVars[obj] = true
+			}
+		}
+		if s.referencesAny(call.Args, loopVars) {
+			return rangeStmt
+		}
+	}
+	return nil
+}
+
+// rangeVarObjects returns the objects of a range statement's key and value
+// variables. Either may be nil if the variable is omitted.
+func (s *State) rangeVarObjects(rangeStmt *ast.RangeStmt) (keyObj, valueObj types.Object) {
+	if rangeStmt.Key != nil {
+		keyObj = s.getObjectForExpr(rangeStmt.Key)
+	}
+	if rangeStmt.Value != nil {
+		valueObj = s.getObjectForExpr(rangeStmt.Value)
+	}
+	return keyObj, valueObj
+}
+
+// referencesAny reports whether any of the expressions refers to one of the objects.
+func (s *State) referencesAny(exprs []ast.Expr, objs map[types.Object]bool) bool {
+	found := false
+	for _, expr := range exprs {
+		ast.Inspect(expr, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok && objs[s.getObjectForExpr(ident)] {
+				found = true
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// resolveCompositeLit resolves the range expression of a loop to the composite
+// literal it iterates over, following variables to their initializers and
+// calls to project functions that return a literal.
+func (s *State) resolveCompositeLit(expr ast.Expr, depth int) *ast.CompositeLit {
+	const maxDepth = 5
+	if expr == nil || depth > maxDepth {
+		return nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.CompositeLit:
+		return e
+	case *ast.ParenExpr:
+		return s.resolveCompositeLit(e.X, depth+1)
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return s.resolveCompositeLit(e.X, depth+1)
+		}
+	case *ast.Ident, *ast.SelectorExpr:
+		return s.resolveCompositeLit(s.findVarInitializer(s.getObjectForExpr(e)), depth+1)
+	case *ast.CallExpr:
+		funcDecl := s.funcDeclForCall(e)
+		if funcDecl == nil || funcDecl.Body == nil {
+			return nil
+		}
+		var result *ast.CompositeLit
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			switch stmt := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				if len(stmt.Results) == 1 && result == nil {
+					result = s.resolveCompositeLit(stmt.Results[0], depth+1)
+				}
+			}
+			return true
+		})
+		return result
+	}
+	return nil
+}
+
+// substituteRangeExpr replaces references to range loop variables in expr
+// with the bound element expressions, e.g. in `"/v1"+rt.Path` or
+// `http.HandlerFunc(rt.Fn)`. A field selector on a bound struct literal
+// (`rt.Path`) is replaced with the literal's field value. Nodes containing a
+// reference are copied, so the original tree and its type information are
+// left intact. Function literals are kept as they are. It returns nil if a
+// reference cannot be substituted.
+func (s *State) substituteRangeExpr(expr ast.Expr, bindings map[types.Object]ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if bound, ok := bindings[s.getObjectForExpr(e)]; ok {
+			return bound
+		}
+		return e
+	case *ast.SelectorExpr:
+		if ident, ok := e.X.(*ast.Ident); ok {
+			if bound, ok := bindings[s.getObjectForExpr(ident)]; ok {
+				return s.compositeLitField(bound, e.Sel.Name)
+			}
+		}
+		x := s.substituteRangeExpr(e.X, bindings)
+		if x == nil {
+			return nil
+		}
+		if x != e.X {
+			substituted := *e
+			substituted.X = x
+			return &substituted
+		}
+		return e
+	case *ast.ParenExpr:
+		x := s.substituteRangeExpr(e.X, bindings)
+		if x == nil {
+			return nil
+		}
+		if x != e.X {
+			substituted := *e
+			substituted.X = x
+			return &substituted
+		}
+		return e
+	case *ast.UnaryExpr:
+		x := s.substituteRangeExpr(e.X, bindings)
+		if x == nil {
+			return nil
+		}
+		if x != e.X {
+			substituted := *e
+			substituted.X = x
+			return &substituted
+		}
+		return e
+	case *ast.BinaryExpr:
+		x, y := s.substituteRangeExpr(e.X, bindings), s.substituteRangeExpr(e.Y, bindings)
+		if x == nil || y == nil {
+			return nil
+		}
+		if x != e.X || y != e.Y {
+			substituted := *e
+			substituted.X, substituted.Y = x, y
+			return &substituted
+		}
+		return e
+	case *ast.CallExpr:
+		fun := s.substituteRangeExpr(e.Fun, bindings)
+		if fun == nil {
+			return nil
+		}
+		args := make([]ast.Expr, len(e.Args))
+		changed := fun != e.Fun
+		for i, arg := range e.Args {
+			if args[i] = s.substituteRangeExpr(arg, bindings); args[i] == nil {
+				return nil
+			}
+			changed = changed || args[i] != arg
+		}
+		if changed {
+			substituted := *e
+			substituted.Fun, substituted.Args = fun, args
+			return &substituted
+		}
+		return e
+	case *ast.FuncLit:
+		return e
+	}
+
+	loopVars := make(map[types.Object]bool)
+	for obj := range bindings {
+		loopVars[obj] = true
+	}
+	if s.referencesAny([]ast.Expr{expr}, loopVars) {
+		return nil
+	}
+	return expr
+}
+
+// compositeLitField returns the value of the named field in a struct literal,
+// supporting both keyed (`{Path: "/x"}`) and positional (`{"GET", "/x"}`) forms.
+func (s *State) compositeLitField(expr ast.Expr, fieldName string) ast.Expr {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == fieldName {
+				return kv.Value
+			}
+		}
+	}
+
+	info := s.getInfoForNode(lit)
+	if info == nil {
+		return nil
+	}
+	litType := info.TypeOf(lit)
+	if ptr, isPtr := litType.(*types.Pointer); isPtr {
+		litType = ptr.Elem()
+	}
+	structType, ok := litType.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < structType.NumFields() && i < len(lit.Elts); i++ {
+		if _, isKV := lit.Elts[i].(*ast.KeyValueExpr); isKV {
+			return nil
+		}
+		if structType.Field(i).Name() == fieldName {
+			return lit.Elts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package analyzer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeUnrollsRangeRegistrations(t *testing.T) {
+	var got []string
+	for _, op := range analyzeFixture(t, "routes") {
+		got = append(got, op.HTTPMethod+" "+op.FullPath+" "+op.HandlerName)
+	}
+	slices.Sort(got)
+
+	want := []string{
+		"GET /health Health",
+		"GET /v1/users ListUsers",
+		"POST /v1/users CreateUser",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("operations = %q, want %q", got, want)
+	}
+}
@@ -13,17 +13,17 @@ import (
 // It iterates over the router definitions in the config, finds the corresponding
 // named types in the project's dependencies, and stores them in the ResolvedRouterTypes map.
 func (s *State) resolveConfigTypes(cfg *config.Config) error {
-	fmt.Println("Phase 1: Resolving configured types...")
+	fmt.Fprintln(s.log, "Phase 1: Resolving configured types...")
 	for i := range cfg.RouterDefinitions {
 		def := &cfg.RouterDefinitions[i]
 		namedType := s.findNamedType(def.Type)
 
 		if namedType == nil {
-			fmt.Printf("  [Warning] Could not find type '%s' defined in config in the project's dependencies.\n", def.Type)
+			fmt.Fprintf(s.log, "  [Warning] Could not find type '%s' defined in config in the project's dependencies.\n", def.Type)
 			continue
 		}
 
-		fmt.Printf("  [Info] Resolved type '%s'\n", def.Type)
+		fmt.Fprintf(s.log, "  [Info] Resolved type '%s'\n", def.Type)
 		s.ResolvedRouterTypes[def.Type] = &ResolvedType{
 			Object:     namedType,
 			Definition: def,
@@ -0,0 +1,462 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Zachacious/go-respec/internal/config"
+	"golang.org/x/tools/go/cfg"
+)
+
+const (
+	mediaTypeJSON   = "application/json"
+	mediaTypeText   = "text/plain"
+	mediaTypeBinary = "application/octet-stream"
+)
+
+// flowState is what is known about the response along one execution path.
+type flowState struct {
+	// status is the status code set by `WriteHeader`, or 0 if none was set.
+	status int
+	// written records that a response body was written.
+	written bool
+	// contentType is the media type set with `w.Header().Set("Content-Type", ...)`.
+	contentType string
+	// headers lists the other response headers set on the path, sorted and
+	// comma-separated so that the state stays comparable.
+	headers string
+}
+
+// withHeader returns the state with a response header added.
+func (st flowState) withHeader(name string) flowState {
+	names := st.headerNames()
+	if containsHeader(names, name) {
+		return st
+	}
+	names = append(names, name)
+	slices.Sort(names)
+	st.headers = strings.Join(names, ",")
+	return st
+}
+
+// headerNames returns the response headers set on the path.
+func (st flowState) headerNames() []string {
+	if st.headers == "" {
+		return nil
+	}
+	return strings.Split(st.headers, ",")
+}
+
+// containsHeader reports whether a list of header names includes name. Header
+// names are case-insensitive, so `ETag` and `Etag` are the same header.
+func containsHeader(names []string, name string) bool {
+	return slices.ContainsFunc(names, func(n string) bool {
+		return http.CanonicalHeaderKey(n) == http.CanonicalHeaderKey(name)
+	})
+}
+
+// describe completes a response written in this state with the media type and
+// headers set before it. A media type chosen by the write itself, such as the
+// text/plain of `http.Error`, is kept.
+func (st flowState) describe(info responseInfo, keepMediaType bool) responseInfo {
+	if st.contentType != "" && !keepMediaType {
+		info.MediaType = st.contentType
+	}
+	for _, name := range st.headerNames() {
+		if !containsHeader(info.Headers, name) {
+			info.Headers = append(info.Headers, name)
+		}
+	}
+	return info
+}
+
+// flowBlock identifies a CFG block reached in a given state.
+type flowBlock struct {
+	block int32
+	state flowState
+}
+
+// responseCollector accumulates the responses found on all paths of a handler.
+type responseCollector struct {
+	patterns  []config.ResponseBodyPattern
+	responses map[int][]responseInfo
+}
+
+// add records a response for a status code, skipping exact duplicates so a
+// type written on several paths is only listed once.
+func (c *responseCollector) add(statusCode int, info responseInfo) {
+	for _, existing := range c.responses[statusCode] {
+		if existing.Description != info.Description || existing.MediaType != info.MediaType ||
+			!slices.Equal(existing.Headers, info.Headers) {
+			continue
+		}
+		if existing.Type == nil && info.Type == nil {
+			return
+		}
+		if existing.Type != nil && info.Type != nil && types.Identical(existing.Type, info.Type) {
+			return
+		}
+	}
+	c.responses[statusCode] = append(c.responses[statusCode], info)
+}
+
+// findResponseSchemas finds the responses of a handler by following its
+// control flow, so that status codes are paired with the bodies written on
+// the same execution path. Calls into project helpers are followed with the
+// state of the calling path.
+func (s *State) findResponseSchemas(body *ast.BlockStmt, patterns []config.ResponseBodyPattern) map[int][]responseInfo {
+	c := &responseCollector{patterns: patterns, responses: make(map[int][]responseInfo)}
+	for _, exit := range s.walkResponseFlow(c, body, &callEnv{}, flowState{}) {
+		// A status written without a body, e.g. `w.WriteHeader(http.StatusAccepted)`.
+		if exit.status != 0 && !exit.written {
+			c.add(exit.status, exit.describe(responseInfo{}, false))
+		}
+	}
+	return c.responses
+}
+
+// walkResponseFlow walks every path through body starting in the entry state
+// and returns the states the function can return in.
+func (s *State) walkResponseFlow(c *responseCollector, body *ast.BlockStmt, env *callEnv, entry flowState) []flowState {
+	if body == nil {
+		return []flowState{entry}
+	}
+
+	graph := cfg.New(body, s.mayReturn)
+	seen := make(map[flowBlock]bool)
+	exits := make(map[flowState]bool)
+	var exitOrder []flowState
+
+	var walk func(block *cfg.Block, state flowState)
+	walk = func(block *cfg.Block, state flowState) {
+		key := flowBlock{block: block.Index, state: state}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		states := []flowState{state}
+		for _, node := range block.Nodes {
+			var next []flowState
+			for _, st := range states {
+				next = appendStates(next, s.applyResponseNode(c, node, env, st)...)
+			}
+			states = next
+		}
+
+		if len(block.Succs) == 0 {
+			if len(block.Nodes) > 0 {
+				if _, isReturn := block.Nodes[len(block.Nodes)-1].(*ast.ReturnStmt); !isReturn {
+					return // The path ends in a call that does not return, e.g. panic.
+				}
+			}
+			for _, st := range states {
+				if !exits[st] {
+					exits[st] = true
+					exitOrder = append(exitOrder, st)
+				}
+			}
+			return
+		}
+		for _, succ := range block.Succs {
+			for _, st := range states {
+				walk(succ, st)
+			}
+		}
+	}
+	walk(graph.Blocks[0], entry)
+	return exitOrder
+}
+
+// applyResponseNode applies the calls in a CFG node to a path state and
+// returns the resulting states. A call into a helper may fork the path.
+func (s *State) applyResponseNode(c *responseCollector, node ast.Node, env *callEnv, state flowState) []flowState {
+	var calls []*ast.CallExpr
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Closures run elsewhere, if at all.
+			return false
+		case *ast.CallExpr:
+			calls = append(calls, n)
+		}
+		return true
+	})
+
+	states := []flowState{state}
+	for _, call := range calls {
+		var next []flowState
+		for _, st := range states {
+			if updated, handled := s.applyResponseCall(c, call, env, st); handled {
+				next = appendStates(next, updated)
+				continue
+			}
+			next = appendStates(next, s.followResponseHelper(c, call, env, st)...)
+		}
+		states = next
+	}
+	return states
+}
+
+// applyResponseCall applies a call that sets the status code or writes a
+// response. It reports false if the call is not recognized.
+func (s *State) applyResponseCall(c *responseCollector, call *ast.CallExpr, env *callEnv, state flowState) (flowState, bool) {
+	obj := s.getObjectForExpr(call.Fun)
+	if obj == nil {
+		return state, false
+	}
+	funcPath := getFuncPath(obj)
+
+	// Built-in "Magic"
+	switch {
+	case funcPath == "net/http.Header.Set" || funcPath == "net/http.Header.Add":
+		return s.applyHeaderCall(call, env, state), true
+	case funcPath == "net/http.ResponseWriter.WriteHeader" && len(call.Args) == 1:
+		if sc, ok := s.resolveIntValueInEnv(call.Args[0], env); ok && !state.written {
+			state.status = sc
+		}
+		return state, true
+	case funcPath == "encoding/json.Encoder.Encode" && len(call.Args) == 1:
+		if t := s.exprType(call.Args[0], env); t != nil {
+			c.add(statusOrOK(state.status), state.describe(responseInfo{Type: t}, false))
+		}
+		state.written = true
+		return state, true
+	case funcPath == "net/http.ResponseWriter.Write" && len(call.Args) == 1:
+		c.add(statusOrOK(state.status), state.describe(s.writtenBodyInfo(call.Args[0], env), false))
+		state.written = true
+		return state, true
+	case funcPath == "net/http.Error" && len(call.Args) == 3:
+		statusCode := http.StatusInternalServerError
+		if sc, ok := s.resolveIntValueInEnv(call.Args[2], env); ok {
+			statusCode = sc
+		}
+		c.add(statusCode, state.describe(responseInfo{Type: types.Typ[types.String], MediaType: mediaTypeText}, true))
+		state.written = true
+		return state, true
+	case funcPath == "net/http.NotFound":
+		c.add(http.StatusNotFound, state.describe(responseInfo{Type: types.Typ[types.String], MediaType: mediaTypeText}, true))
+		state.written = true
+		return state, true
+	case funcPath == "net/http.Redirect" && len(call.Args) == 4:
+		statusCode := http.StatusFound
+		if sc, ok := s.resolveIntValueInEnv(call.Args[3], env); ok {
+			statusCode = sc
+		}
+		c.add(statusCode, state.describe(responseInfo{Headers: []string{"Location"}}, false))
+		state.written = true
+		return state, true
+	case funcPath == "net/http.ServeContent", funcPath == "net/http.ServeFile", funcPath == "net/http.ServeFileFS":
+		c.add(http.StatusOK, state.describe(responseInfo{MediaType: mediaTypeBinary, Binary: true}, false))
+		state.written = true
+		return state, true
+	}
+
+	// User-configured patterns
+	for _, p := range c.patterns {
+		if funcPath != p.FunctionPath {
+			continue
+		}
+		statusCode := 200
+		var desc string
+
+		if p.StatusCodeIndex != nil && len(call.Args) > *p.StatusCodeIndex {
+			if sc, ok := s.resolveIntValueInEnv(call.Args[*p.StatusCodeIndex], env); ok {
+				statusCode = sc
+			}
+		} else if p.StatusCodeChain != nil {
+			if sc, ok := s.chainedStatusCode(call, p.StatusCodeChain); ok {
+				statusCode = sc
+			}
+		}
+
+		if p.DescriptionIndex != nil && len(call.Args) > *p.DescriptionIndex {
+			if d, ok := s.resolveStringValueInEnv(call.Args[*p.DescriptionIndex], env); ok {
+				desc = d
+			}
+		}
+
+		info := responseInfo{Description: desc}
+		if p.DataIndex >= 0 && len(call.Args) > p.DataIndex {
+			t := s.exprType(call.Args[p.DataIndex], env)
+			if t == nil {
+				state.written = true
+				return state, true
+			}
+			info.Type = t
+		}
+		// A pattern for a project helper describes the write, but the helper
+		// may still set headers and the media type before it.
+		for _, st := range s.helperHeaderStates(c, call, env, state) {
+			c.add(statusCode, st.describe(info, false))
+		}
+		state.written = true
+		return state, true
+	}
+	return state, false
+}
+
+// helperHeaderStates walks the project function invoked by call, if any, and
+// returns the state with the media type and headers set on each of its paths.
+// The responses the helper writes are discarded, as the pattern matching the
+// call describes them.
+func (s *State) helperHeaderStates(c *responseCollector, call *ast.CallExpr, env *callEnv, state flowState) []flowState {
+	helperEnv := s.helperEnv(call, env)
+	if helperEnv == nil {
+		return []flowState{state}
+	}
+	discard := &responseCollector{patterns: c.patterns, responses: make(map[int][]responseInfo)}
+	var states []flowState
+	for _, exit := range s.walkResponseFlow(discard, helperEnv.fn.Body, helperEnv, state) {
+		st := state
+		st.contentType, st.headers = exit.contentType, exit.headers
+		states = appendStates(states, st)
+	}
+	if len(states) == 0 {
+		return []flowState{state}
+	}
+	return states
+}
+
+// writtenBodyInfo describes the body passed to `ResponseWriter.Write`: JSON
+// for bytes produced by json.Marshal, text for bytes converted from a string,
+// and binary otherwise.
+func (s *State) writtenBodyInfo(expr ast.Expr, env *callEnv) responseInfo {
+	for {
+		arg, argEnv, ok := s.binding(expr, env)
+		if !ok {
+			break
+		}
+		expr, env = arg, argEnv
+	}
+	expr = ast.Unparen(expr)
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		// `data, err := json.Marshal(v)` followed by `w.Write(data)`.
+		if call, index := s.findInitializerCall(s.getObjectForExpr(ident)); call != nil && index == 0 {
+			switch getFuncPath(s.getObjectForExpr(call.Fun)) {
+			case "encoding/json.Marshal", "encoding/json.MarshalIndent":
+				if len(call.Args) > 0 {
+					if t := s.exprType(call.Args[0], env); t != nil {
+						return responseInfo{Type: t}
+					}
+				}
+			}
+		}
+	}
+
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		// A conversion such as `[]byte("ok")` or `[]byte(msg)`.
+		if info := s.getInfoForNode(call.Fun); info != nil && info.Types[call.Fun].IsType() {
+			if t := s.exprType(call.Args[0], env); t != nil {
+				if basic, ok := t.Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+					return responseInfo{Type: types.Typ[types.String], MediaType: mediaTypeText}
+				}
+			}
+		}
+	}
+	return responseInfo{MediaType: mediaTypeBinary, Binary: true}
+}
+
+// applyHeaderCall applies a `w.Header().Set(name, value)` or `Add` call to a
+// path state. Content-Type sets the media type of the response, other names
+// are recorded as response headers. Headers changed after the status or body
+// was written are not sent and are ignored, as are headers of other values
+// such as `r.Header`.
+func (s *State) applyHeaderCall(call *ast.CallExpr, env *callEnv, state flowState) flowState {
+	if state.status != 0 || state.written || len(call.Args) != 2 {
+		return state
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return state
+	}
+	var headerCall *ast.CallExpr
+	switch x := ast.Unparen(sel.X).(type) {
+	case *ast.CallExpr:
+		headerCall = x
+	case *ast.Ident:
+		// `h := w.Header()` followed by `h.Set(...)`.
+		headerCall, _ = s.findInitializerCall(s.getObjectForExpr(x))
+	}
+	if headerCall == nil || getFuncPath(s.getObjectForExpr(headerCall.Fun)) != "net/http.ResponseWriter.Header" {
+		return state
+	}
+	name, ok := s.resolveStringValueInEnv(call.Args[0], env)
+	if !ok {
+		return state
+	}
+
+	// Headers are documented with the name as written, e.g. `ETag`.
+	if http.CanonicalHeaderKey(name) != "Content-Type" {
+		return state.withHeader(name)
+	}
+	if value, ok := s.resolveStringValueInEnv(call.Args[1], env); ok {
+		if mediaType, _, err := mime.ParseMediaType(value); err == nil {
+			state.contentType = mediaType
+		}
+	}
+	return state
+}
+
+// followResponseHelper walks the project function invoked by call, if any,
+// with the helper's parameters bound to the call's arguments, and returns the
+// states it can return in.
+func (s *State) followResponseHelper(c *responseCollector, call *ast.CallExpr, env *callEnv, state flowState) []flowState {
+	helperEnv := s.helperEnv(call, env)
+	if helperEnv == nil {
+		return []flowState{state}
+	}
+	exits := s.walkResponseFlow(c, helperEnv.fn.Body, helperEnv, state)
+	if len(exits) == 0 {
+		// The helper never returns normally (e.g. it always panics).
+		return []flowState{state}
+	}
+	return exits
+}
+
+// mayReturn reports whether a call may return, for building CFGs. Calls to
+// panic, os.Exit and log.Fatal end the path.
+func (s *State) mayReturn(call *ast.CallExpr) bool {
+	if ident, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+		if info := s.getInfoForNode(ident); info != nil {
+			if builtin, ok := info.Uses[ident].(*types.Builtin); ok && builtin.Name() == "panic" {
+				return false
+			}
+		}
+	}
+	switch getFuncPath(s.getObjectForExpr(call.Fun)) {
+	case "os.Exit", "log.Fatal", "log.Fatalf", "log.Fatalln", "log.Panic", "log.Panicf", "log.Panicln":
+		return false
+	}
+	return true
+}
+
+// statusOrOK returns the status code, or 200 if none was set.
+func statusOrOK(status int) int {
+	if status == 0 {
+		return 200
+	}
+	return status
+}
+
+// appendStates appends states to a list, skipping duplicates.
+func appendStates(list []flowState, states ...flowState) []flowState {
+	for _, st := range states {
+		duplicate := false
+		for _, existing := range list {
+			if existing == st {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			list = append(list, st)
+		}
+	}
+	return list
+}
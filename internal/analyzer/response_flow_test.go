@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Zachacious/go-respec/internal/config"
+)
+
+func TestConfiguredHelperPatternKeepsHelperHeaders(t *testing.T) {
+	s := loadFixture(t, "responses", func(cfg *config.Config) {
+		cfg.HandlerPatterns.ResponseBody = append(cfg.HandlerPatterns.ResponseBody, config.ResponseBodyPattern{
+			FunctionPath:    "example.com/responses.writeJSON",
+			DataIndex:       2,
+			StatusCodeIndex: intPtr(1),
+		})
+	})
+
+	responses := s.findResponseSchemas(funcBody(t, s, "GetUser"), s.Config.HandlerPatterns.ResponseBody)
+	if len(responses) != 1 || len(responses[200]) != 1 {
+		t.Fatalf("responses = %+v, want one 200 response", responses)
+	}
+	info := responses[200][0]
+	if info.Type == nil || info.Type.String() != "example.com/responses.User" {
+		t.Errorf("type = %v, want example.com/responses.User", info.Type)
+	}
+	if info.MediaType != "application/vnd.api+json" {
+		t.Errorf("media type = %q, want application/vnd.api+json", info.MediaType)
+	}
+	if !slices.Equal(info.Headers, []string{"X-Request-Id"}) {
+		t.Errorf("headers = %v, want [X-Request-Id]", info.Headers)
+	}
+}
+
+func TestAnalyzeWalksUnconfiguredHelpers(t *testing.T) {
+	ops := analyzeFixture(t, "responses")
+	if len(ops) != 1 {
+		t.Fatalf("found %d operations, want 1", len(ops))
+	}
+
+	response := ops[0].Spec.Responses.Value("200")
+	if response == nil || response.Value == nil {
+		t.Fatalf("missing 200 response")
+	}
+	if response.Value.Content.Get("application/vnd.api+json") == nil {
+		t.Errorf("content = %v, want application/vnd.api+json", response.Value.Content)
+	}
+	if response.Value.Headers["X-Request-Id"] == nil {
+		t.Errorf("headers = %v, want X-Request-Id", response.Value.Headers)
+	}
+}
+
+func TestFindResponseSchemasFollowsControlFlow(t *testing.T) {
+	s := loadFixture(t, "responses", nil)
+
+	tests := []struct {
+		handler string
+		// want lists the response types by status code, "" for no body.
+		want map[int][]string
+	}{
+		{"CreateUser", map[int][]string{
+			400: {"example.com/responses.APIError"},
+			201: {"example.com/responses.User"},
+		}},
+		{"GetAccount", map[int][]string{
+			200: {"example.com/responses.Admin", "example.com/responses.User"},
+		}},
+		{"DeleteUser", map[int][]string{
+			202: {""},
+			204: {""},
+		}},
+		{"UpdateUser", map[int][]string{
+			404: {"string"},
+			200: {"example.com/responses.User"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.handler, func(t *testing.T) {
+			responses := s.findResponseSchemas(funcBody(t, s, tt.handler), s.Config.HandlerPatterns.ResponseBody)
+			got := make(map[int][]string)
+			for status, infos := range responses {
+				for _, info := range infos {
+					name := ""
+					if info.Type != nil {
+						name = info.Type.String()
+					}
+					got[status] = append(got[status], name)
+				}
+				slices.Sort(got[status])
+			}
+			if !maps.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("responses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildResponseCombinesBodiesWithOneOf(t *testing.T) {
+	s := loadFixture(t, "responses", nil)
+
+	responses := s.findResponseSchemas(funcBody(t, s, "GetAccount"), s.Config.HandlerPatterns.ResponseBody)
+	response := s.buildResponse(200, responses[200])
+	mediaType := response.Content.Get("application/json")
+	if mediaType == nil || mediaType.Schema == nil || mediaType.Schema.Value == nil {
+		t.Fatalf("missing application/json schema in %v", response.Content)
+	}
+	if n := len(mediaType.Schema.Value.OneOf); n != 2 {
+		t.Errorf("oneOf has %d schemas, want 2", n)
+	}
+}
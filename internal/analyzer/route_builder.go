@@ -2,179 +2,332 @@ package analyzer
 
 import (
 	"go/ast"
-	"go/types"
 	"regexp"
-	"strconv"
+	"slices"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/Zachacious/go-respec/internal/config"
 	"github.com/Zachacious/go-respec/internal/model"
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
-func (s *State) buildRouteFromCall(val *TrackedValue, call *ast.CallExpr, handlerDecl *ast.FuncDecl) {
-	selExpr, ok := call.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return
+// endpointRegistration returns how a method call on a router registers an
+// endpoint, or nil if the method is not an endpoint method. Methods listed in
+// EndpointMethods are named after their HTTP method and take (path, handler),
+// with the handler at EndpointHandlerIndex if it is set.
+func endpointRegistration(def *config.RouterDefinition, methodName string) *config.EndpointRegistration {
+	for i := range def.EndpointRegistrations {
+		if def.EndpointRegistrations[i].Name == methodName {
+			return &def.EndpointRegistrations[i]
+		}
 	}
-	httpMethod := strings.ToUpper(selExpr.Sel.Name)
-	if len(call.Args) < 2 {
+	if slices.Contains(def.EndpointMethods, methodName) {
+		handlerIndex := 1
+		if def.EndpointHandlerIndex != nil {
+			handlerIndex = *def.EndpointHandlerIndex
+		}
+		return &config.EndpointRegistration{Name: methodName, PathIndex: 0, HandlerIndex: handlerIndex}
+	}
+	return nil
+}
+
+// argAt returns the argument at index, where negative indexes count from the
+// end of the argument list (-1 is the last argument).
+func argAt(args []ast.Expr, index int) ast.Expr {
+	if index < 0 {
+		index += len(args)
+	}
+	if index < 0 || index >= len(args) {
+		return nil
+	}
+	return args[index]
+}
+
+// buildRouteFromCall builds the operations registered by an endpoint method
+// call. args are the call's arguments, or the arguments of one element of an
+// unrolled table-driven registration.
+func (s *State) buildRouteFromCall(val *TrackedValue, call *ast.CallExpr, args []ast.Expr, reg *config.EndpointRegistration) {
+	pathArg := argAt(args, reg.PathIndex)
+	handlerArg := argAt(args, reg.HandlerIndex)
+	if pathArg == nil || handlerArg == nil {
 		return
 	}
-	pathArg := call.Args[0]
 	path, ok := s.resolveStringValue(pathArg)
 	if !ok {
 		return
 	}
 
-	fullPath := s.assembleFullPath(val, path)
-	if len(fullPath) > 1 && strings.HasSuffix(fullPath, "/") {
-		fullPath = fullPath[:len(fullPath)-1]
-	}
-
-	handlerArg := call.Args[1]
-	var handlerObj types.Object
-	var originalHandlerDecl *ast.FuncDecl = handlerDecl
-	finalHandlerExpr := handlerArg
-
-	if callExpr, ok := handlerArg.(*ast.CallExpr); ok {
-		if sel, ok := callExpr.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Unwrap" {
-			if _, realHandler := s.parseHandlerChain(sel.X); realHandler != nil {
-				finalHandlerExpr = realHandler
+	var httpMethods []string
+	anyMethod := false
+	switch {
+	case reg.MethodIndex != nil:
+		methodArg := argAt(args, *reg.MethodIndex)
+		if methodArg == nil {
+			return
+		}
+		// The argument is a single method or, as in echo's
+		// `e.Match([]string{"GET", "HEAD"}, path, h)`, a list of methods.
+		methodExprs := []ast.Expr{methodArg}
+		if lit, ok := methodArg.(*ast.CompositeLit); ok {
+			methodExprs = lit.Elts
+		}
+		for _, expr := range methodExprs {
+			if method, ok := s.resolveStringValue(expr); ok {
+				httpMethods = append(httpMethods, strings.ToUpper(method))
 			}
 		}
+		if len(httpMethods) == 0 {
+			return
+		}
+	case reg.AnyMethod:
+		anyMethod = true
+	default:
+		httpMethods = []string{strings.ToUpper(reg.Name)}
 	}
 
-	handlerObj = s.getObjectForExpr(finalHandlerExpr)
+	var host string
+	exactMatch := false
+	if val.RouterDef.PathSyntax == config.PathSyntaxServeMux {
+		// ServeMux patterns carry the method and host, e.g. "GET example.com/users/{id}".
+		pattern := parseServeMuxPattern(path)
+		path, host = pattern.Path, pattern.Host
+		// "/users/{$}" matches only "/users/", which keeps its trailing slash.
+		exactMatch = strings.HasSuffix(path, "/{$}")
+		if pattern.Method != "" {
+			httpMethods = []string{pattern.Method}
+			anyMethod = false
+		}
+	}
+	if chained := s.chainedHTTPMethods(call, val.RouterDef); len(chained) > 0 {
+		httpMethods = chained
+		anyMethod = false
+	}
+	if anyMethod {
+		httpMethods = val.RouterDef.AnyMethodVerbs
+		if len(httpMethods) == 0 {
+			httpMethods = anyMethodVerbs
+		}
+	}
 
-	if handlerObj == nil {
+	handler := s.resolveHandler(handlerArg)
+	if handler == nil {
 		return
 	}
+	handlerObj := handler.Object
 
-	if originalHandlerDecl == nil || s.getObjectForExpr(handlerArg) != handlerObj {
-		originalHandlerDecl = s.Universe.Functions[handlerObj]
+	// fiber's optional parameters, e.g. `/users/:id?`, match the path with and
+	// without them.
+	rawPaths := []string{s.assembleFullPath(val, path)}
+	if val.RouterDef.PathSyntax == config.PathSyntaxColon {
+		rawPaths = expandOptionalParams(rawPaths[0])
 	}
 
-	op := &model.Operation{
-		HTTPMethod:  httpMethod,
-		FullPath:    fullPath,
-		GoHandler:   handlerObj,
-		HandlerName: handlerObj.Name(),
-		Spec:        openapi3.NewOperation(),
-	}
-	if handlerObj.Pkg() != nil {
-		op.HandlerPackage = handlerObj.Pkg().Path()
-	}
+	for _, rawPath := range rawPaths {
+		fullPath, paramPatterns, wildcards := normalizePath(val.RouterDef.PathSyntax, rawPath)
+		if len(fullPath) > 1 && strings.HasSuffix(fullPath, "/") && !exactMatch {
+			fullPath = fullPath[:len(fullPath)-1]
+		}
 
-	if metadata, ok := s.OperationMetadata[handlerObj]; ok {
-		op.HandlerMetadata = metadata
-	}
+		for _, httpMethod := range httpMethods {
+			op := &model.Operation{
+				HTTPMethod:  httpMethod,
+				FullPath:    fullPath,
+				GoHandler:   handlerObj,
+				HandlerBody: handler.Body,
+				HandlerDoc:  handler.Doc,
+				Spec:        openapi3.NewOperation(),
+			}
+			if handlerObj != nil {
+				op.HandlerName = handlerObj.Name()
+				if handlerObj.Pkg() != nil {
+					op.HandlerPackage = handlerObj.Pkg().Path()
+				}
+			} else {
+				// An inline function literal has no name of its own.
+				op.HandlerName = operationName(httpMethod, fullPath)
+			}
+			if host != "" {
+				// The route only matches requests for this host.
+				op.Spec.Servers = &openapi3.Servers{{URL: "//" + host}}
+			}
+			if anyMethod {
+				// Flag operations that were expanded from a registration matching every method.
+				op.Spec.Extensions = map[string]any{anyMethodExtension: true}
+			}
+
+			if metadata, ok := s.OperationMetadata[handlerObj]; ok {
+				op.HandlerMetadata = metadata
+			}
 
-	routeNode := val.Node
-	routeNode.Operations = append(routeNode.Operations, op)
+			routeNode := val.Node
+			routeNode.Operations = append(routeNode.Operations, op)
 
-	re := regexp.MustCompile(`\{(\w+)\}`)
-	matches := re.FindAllStringSubmatch(fullPath, -1)
-	for _, match := range matches {
-		if len(match) > 1 {
-			paramName := match[1]
-			param := openapi3.NewPathParameter(paramName).WithSchema(openapi3.NewStringSchema())
-			if originalHandlerDecl != nil && originalHandlerDecl.Body != nil {
-				s.inferPathParameterType(originalHandlerDecl.Body, param)
+			re := regexp.MustCompile(`\{(\w+)\}`)
+			matches := re.FindAllStringSubmatch(fullPath, -1)
+			for _, match := range matches {
+				if len(match) > 1 {
+					paramName := match[1]
+					param := openapi3.NewPathParameter(paramName).WithSchema(openapi3.NewStringSchema())
+					if handler.Body != nil {
+						s.inferPathParameterType(handler.Body, param, wildcards[paramName])
+					}
+					if pattern, ok := paramPatterns[paramName]; ok && param.Schema.Value.Type.Is("string") {
+						// Carry the router's regex constraint over, e.g. `{id:[0-9]+}`.
+						param.Schema.Value.Pattern = pattern
+					}
+					op.Spec.AddParameter(param)
+				}
 			}
-			op.Spec.AddParameter(param)
 		}
 	}
 }
 
-// inferPathParameterType scans a handler body to infer a more specific schema for a path parameter.
-func (s *State) inferPathParameterType(body *ast.BlockStmt, param *openapi3.Parameter) {
-	var paramVarObj types.Object
-
-	ast.Inspect(body, func(n ast.Node) bool {
-		assign, ok := n.(*ast.AssignStmt)
-		if !ok || len(assign.Lhs) == 0 || len(assign.Rhs) != 1 {
-			return true
+// operationName derives a handler name from an operation's method and path,
+// e.g. "getUsersById" for GET /users/{id}.
+func operationName(httpMethod, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(httpMethod))
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			b.WriteString("By")
+			segment = strings.TrimSuffix(name, "}")
 		}
-		call, ok := assign.Rhs[0].(*ast.CallExpr)
-		if !ok {
-			return true
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			first, size := utf8.DecodeRuneInString(word)
+			b.WriteRune(unicode.ToUpper(first))
+			b.WriteString(word[size:])
 		}
+	}
+	return b.String()
+}
 
-		selExpr, ok := call.Fun.(*ast.SelectorExpr)
-		if !ok {
-			return true
-		}
+// chainedHTTPMethods returns the HTTP methods set by a method-chain call on an
+// endpoint registration, e.g. gorilla's `r.HandleFunc("/", h).Methods("GET")`.
+func (s *State) chainedHTTPMethods(call *ast.CallExpr, def *config.RouterDefinition) []string {
+	if len(def.MethodChainMethods) == 0 {
+		return nil
+	}
 
-		info := s.getInfoForNode(selExpr.Sel)
-		if info == nil {
-			return true
+	for current := call; ; {
+		path, found := s.findPathToNode(current)
+		if !found || len(path) < 3 {
+			return nil
+		}
+		sel, ok := path[1].(*ast.SelectorExpr)
+		if !ok || sel.X != current {
+			return nil
+		}
+		next, ok := path[2].(*ast.CallExpr)
+		if !ok || next.Fun != sel {
+			return nil
 		}
 
-		if funObj := info.Uses[selExpr.Sel]; funObj != nil && getFuncPath(funObj) == "github.com/go-chi/chi/v5.URLParam" {
-			if len(call.Args) == 2 {
-				if nameLit, ok := call.Args[1].(*ast.BasicLit); ok {
-					if name, err := strconv.Unquote(nameLit.Value); err == nil && name == param.Name {
-						if ident, ok := assign.Lhs[0].(*ast.Ident); ok {
-							paramVarObj = s.getInfoForNode(ident).Defs[ident]
-							return false // Stop searching
-						}
-					}
+		if slices.Contains(def.MethodChainMethods, sel.Sel.Name) {
+			var methods []string
+			for _, arg := range next.Args {
+				if method, ok := s.resolveStringValue(arg); ok {
+					methods = append(methods, strings.ToUpper(method))
 				}
 			}
+			return methods
 		}
-		return true
-	})
-
-	if paramVarObj == nil {
-		return
+		current = next
 	}
+}
 
+// inferPathParameterType scans a handler body to infer a more specific schema for a path parameter.
+// token is the name handlers read a renamed wildcard parameter by, or empty.
+func (s *State) inferPathParameterType(body *ast.BlockStmt, param *openapi3.Parameter, token string) {
+	name := param.Name
+	if token != "" {
+		name = token
+	}
 	ast.Inspect(body, func(n ast.Node) bool {
-		ident, ok := n.(*ast.Ident)
-		if !ok {
+		access, ok := n.(ast.Expr)
+		if !ok || !s.isPathParameterAccess(access, name) {
 			return true
 		}
+		return !s.inferValueType(access, param.Schema.Value)
+	})
+}
 
-		info := s.getInfoForNode(ident)
-		if info == nil || info.Uses[ident] != paramVarObj {
-			return true
+// isPathParameterAccess reports whether an expression reads the named path
+// parameter through one of the configured path parameter patterns, either as a
+// call (`chi.URLParam(r, "id")`) or as an index into a parameter map
+// (`mux.Vars(r)["id"]`, or `vars["id"]` after `vars := mux.Vars(r)`).
+func (s *State) isPathParameterAccess(expr ast.Expr, name string) bool {
+	if index, ok := expr.(*ast.IndexExpr); ok {
+		source := index.X
+		if obj := s.getObjectForExpr(source); obj != nil {
+			if init := s.findVarInitializer(obj); init != nil {
+				source = init
+			}
 		}
-
-		path, _ := s.findPathToNode(ident)
-		if len(path) < 2 {
-			return true
+		call, ok := source.(*ast.CallExpr)
+		if !ok {
+			return false
 		}
-
-		if call, ok := path[1].(*ast.CallExpr); ok {
-			funInfo := s.getInfoForNode(call.Fun)
-			if funInfo == nil {
-				return true
+		funcPath := getFuncPath(s.getObjectForExpr(call.Fun))
+		for _, p := range s.Config.HandlerPatterns.PathParameter {
+			if p.MapIndex && funcPath == p.FunctionPath {
+				argName, ok := s.resolveStringValue(index.Index)
+				return ok && argName == name
 			}
+		}
+		return false
+	}
 
-			var funObj types.Object
-			if sel, isSel := call.Fun.(*ast.SelectorExpr); isSel {
-				funObj = funInfo.Uses[sel.Sel]
-			} else if funIdent, isIdent := call.Fun.(*ast.Ident); isIdent {
-				funObj = funInfo.Uses[funIdent]
-			}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	funcPath := getFuncPath(s.getObjectForExpr(call.Fun))
+	for _, p := range s.Config.HandlerPatterns.PathParameter {
+		if p.MapIndex || funcPath != p.FunctionPath || len(call.Args) <= p.NameIndex {
+			continue
+		}
+		if argName, ok := s.resolveStringValue(call.Args[p.NameIndex]); ok && argName == name {
+			return true
+		}
+	}
+	return false
+}
 
-			if funObj != nil {
-				switch getFuncPath(funObj) {
-				case "strconv.Atoi", "strconv.ParseInt":
-					param.Schema.Value.Type = &openapi3.Types{"integer"}
-					return false
-				case "strconv.ParseFloat":
-					param.Schema.Value.Type = &openapi3.Types{"number"}
-					param.Schema.Value.Format = "double"
-					return false
-				case "github.com/google/uuid.Parse":
-					param.Schema.Value.Format = "uuid"
-					return false
-				}
-			}
+// applyConversionType refines a string schema based on the function a
+// parameter value is passed to. It reports whether the call was recognized.
+func (s *State) applyConversionType(call *ast.CallExpr, schema *openapi3.Schema) bool {
+	switch getFuncPath(s.getObjectForExpr(call.Fun)) {
+	case "strconv.Atoi", "strconv.ParseInt", "strconv.ParseUint":
+		schema.Type = &openapi3.Types{"integer"}
+		return true
+	case "strconv.ParseFloat":
+		schema.Type = &openapi3.Types{"number"}
+		schema.Format = "double"
+		return true
+	case "strconv.ParseBool":
+		schema.Type = &openapi3.Types{"boolean"}
+		return true
+	case "time.Parse":
+		schema.Format = "date-time"
+		if layout, ok := s.resolveStringValue(call.Args[0]); ok && layout == time.DateOnly {
+			schema.Format = "date"
 		}
 		return true
-	})
+	case "strings.Split":
+		// A delimited list such as `?ids=1,2,3`.
+		schema.Type = &openapi3.Types{"array"}
+		schema.Items = openapi3.NewStringSchema().NewRef()
+		return true
+	case "github.com/google/uuid.Parse":
+		schema.Format = "uuid"
+		return true
+	}
+	return false
 }
 
 // assembleFullPath walks up the chain of tracked values to construct the
@@ -1,34 +1,66 @@
 package analyzer
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/types"
 	"reflect"
+	"sort"
 	"strings"
 
+	"github.com/Zachacious/go-respec/internal/config"
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
+// componentRefPrefix is the prefix of $refs to schema components.
+const componentRefPrefix = "#/components/schemas/"
+
 // SchemaGenerator turns Go types into OpenAPI schema definitions.
 type SchemaGenerator struct {
 	// A cache to store schemas for types we've already processed to avoid re-computation.
 	cache map[types.Type]*openapi3.SchemaRef
 	// The final map of named components that will be added to the spec.
 	Components map[string]*openapi3.SchemaRef
+	// config holds the schema options of the configuration.
+	config config.SchemaConfig
+	// enums holds the constants of named types that are enums.
+	enums map[*types.TypeName][]enumValue
+	// docs holds the doc comments of named types and struct fields.
+	docs map[types.Object]string
+
+	// request is set while a request body schema is generated. When the
+	// request and response required policies differ, request schemas are
+	// generated apart, into requestComponents, until MergeRequestComponents.
+	request           bool
+	requestCache      map[types.Type]*openapi3.SchemaRef
+	requestComponents map[string]*openapi3.SchemaRef
+	// requestRefs are the $refs to each request component, retargeted if the
+	// component is added under a name of its own.
+	requestRefs map[string][]*openapi3.SchemaRef
 }
 
-// NewSchemaGenerator returns a new SchemaGenerator instance.
-func NewSchemaGenerator() *SchemaGenerator {
-	return &SchemaGenerator{
-		cache:      make(map[types.Type]*openapi3.SchemaRef),
-		Components: make(map[string]*openapi3.SchemaRef),
+// NewSchemaGenerator returns a new SchemaGenerator instance. cfg may be nil.
+func NewSchemaGenerator(cfg *config.SchemaConfig) *SchemaGenerator {
+	sg := &SchemaGenerator{
+		cache:             make(map[types.Type]*openapi3.SchemaRef),
+		Components:        make(map[string]*openapi3.SchemaRef),
+		requestCache:      make(map[types.Type]*openapi3.SchemaRef),
+		requestComponents: make(map[string]*openapi3.SchemaRef),
+		requestRefs:       make(map[string][]*openapi3.SchemaRef),
+		enums:             make(map[*types.TypeName][]enumValue),
+		docs:              make(map[types.Object]string),
 	}
+	if cfg != nil {
+		sg.config = *cfg
+	}
+	return sg
 }
 
 // GenerateSchema is the main public entry point for creating a schema from a Go type.
 func (sg *SchemaGenerator) GenerateSchema(t types.Type) *openapi3.SchemaRef {
+	cache, _ := sg.tables()
 	// If we have already processed this exact type, return the cached version.
-	if ref, ok := sg.cache[t]; ok {
+	if ref, ok := cache[t]; ok {
 		return ref
 	}
 
@@ -36,10 +68,89 @@ func (sg *SchemaGenerator) GenerateSchema(t types.Type) *openapi3.SchemaRef {
 	schemaRef := sg.buildSchemaRef(t)
 
 	// Cache the result for this type to handle recursion and avoid re-work.
-	sg.cache[t] = schemaRef
+	cache[t] = schemaRef
 	return schemaRef
 }
 
+// GenerateRequestSchema creates the schema of a request body, applying the
+// request required policy.
+func (sg *SchemaGenerator) GenerateRequestSchema(t types.Type) *openapi3.SchemaRef {
+	if sg.requiredPolicy(true) == sg.requiredPolicy(false) {
+		return sg.GenerateSchema(t)
+	}
+	sg.request = true
+	defer func() { sg.request = false }()
+	return sg.GenerateSchema(t)
+}
+
+// MergeRequestComponents adds the request variants of components to
+// Components. A variant equal to the response component is dropped; one that
+// differs, or refers to a variant that differs, is added as "<Name>Request".
+func (sg *SchemaGenerator) MergeRequestComponents() {
+	differs := make(map[string]bool)
+	for name, ref := range sg.requestComponents {
+		if existing, ok := sg.Components[name]; ok && !sameSchema(existing.Value, ref.Value) {
+			differs[name] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, ref := range sg.requestComponents {
+			if _, ok := sg.Components[name]; !ok || differs[name] {
+				continue
+			}
+			visitSchemaRefs(ref.Value, func(r *openapi3.SchemaRef) {
+				if differs[strings.TrimPrefix(r.Ref, componentRefPrefix)] && !differs[name] {
+					differs[name] = true
+					changed = true
+				}
+			})
+		}
+	}
+
+	names := make([]string, 0, len(sg.requestComponents))
+	for name := range sg.requestComponents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !differs[name] {
+			if _, ok := sg.Components[name]; !ok {
+				sg.Components[name] = sg.requestComponents[name]
+			}
+			continue
+		}
+		variant := name + "Request"
+		for _, taken := sg.Components[variant]; taken; _, taken = sg.Components[variant] {
+			variant += "Request"
+		}
+		sg.Components[variant] = sg.requestComponents[name]
+		for _, ref := range sg.requestRefs[name] {
+			ref.Ref = componentRefPrefix + variant
+		}
+	}
+	clear(sg.requestComponents)
+}
+
+// tables returns the schema cache and component map of the schemas being
+// generated, request or response.
+func (sg *SchemaGenerator) tables() (map[types.Type]*openapi3.SchemaRef, map[string]*openapi3.SchemaRef) {
+	if sg.request {
+		return sg.requestCache, sg.requestComponents
+	}
+	return sg.cache, sg.Components
+}
+
+// componentRef returns a $ref to a component, recording it for
+// MergeRequestComponents while a request schema is generated.
+func (sg *SchemaGenerator) componentRef(name string) *openapi3.SchemaRef {
+	ref := openapi3.NewSchemaRef(componentRefPrefix+name, nil)
+	if sg.request {
+		sg.requestRefs[name] = append(sg.requestRefs[name], ref)
+	}
+	return ref
+}
+
 // buildSchemaRef is the main dispatcher. It correctly decides whether to create a reusable
 // component with a $ref, or an inline schema definition.
 func (sg *SchemaGenerator) buildSchemaRef(t types.Type) *openapi3.SchemaRef {
@@ -58,27 +169,31 @@ func (sg *SchemaGenerator) buildSchemaRef(t types.Type) *openapi3.SchemaRef {
 				return &openapi3.SchemaRef{Value: sg.schemaForStruct(s)}
 			}
 
-			refPath := "#/components/schemas/" + componentName
+			cache, components := sg.tables()
 
 			// If this component is already being processed, we've hit a recursive loop.
 			// Return the reference to the placeholder that has already been created.
-			if _, ok := sg.Components[componentName]; ok {
-				return openapi3.NewSchemaRef(refPath, nil)
+			if _, ok := components[componentName]; ok {
+				return sg.componentRef(componentName)
 			}
 
 			// Create a placeholder Schema. This will be the value for our component.
 			placeholderSchema := &openapi3.Schema{}
-			sg.Components[componentName] = &openapi3.SchemaRef{Value: placeholderSchema}
+			components[componentName] = &openapi3.SchemaRef{Value: placeholderSchema}
 
 			// Create a reference to this new component and cache it.
-			ref := openapi3.NewSchemaRef(refPath, nil)
-			sg.cache[t] = ref
+			ref := sg.componentRef(componentName)
+			cache[t] = ref
 
 			// Now, build the actual schema properties and populate the placeholder.
 			*placeholderSchema = *sg.schemaForStruct(s)
+			sg.describe(placeholderSchema, u.Obj())
 			return ref
+		} else if values, ok := sg.enums[u.Obj()]; ok {
+			// Case 2: A named type with constants, e.g. `type OrderStatus string`.
+			return sg.enumSchemaRef(u, values)
 		} else {
-			// Case 2: It's a named type but not a struct (e.g., type UserID string).
+			// Case 3: It's a named type but not a struct (e.g., type UserID string).
 			// We should not create a component for it, but instead use the schema
 			// for its underlying basic type (e.g., 'string').
 			return sg.GenerateSchema(underlying)
@@ -95,7 +210,7 @@ func (sg *SchemaGenerator) buildSchemaRef(t types.Type) *openapi3.SchemaRef {
 		schema.AdditionalProperties = openapi3.AdditionalProperties{Schema: sg.GenerateSchema(u.Elem())}
 		return &openapi3.SchemaRef{Value: schema}
 	case *types.Struct:
-		// Case 3: This is an anonymous struct. It must be defined inline.
+		// Case 4: This is an anonymous struct. It must be defined inline.
 		return &openapi3.SchemaRef{Value: sg.schemaForStruct(u)}
 	case *types.Basic:
 		return &openapi3.SchemaRef{Value: sg.schemaForBasic(u)}
@@ -106,6 +221,33 @@ func (sg *SchemaGenerator) buildSchemaRef(t types.Type) *openapi3.SchemaRef {
 	}
 }
 
+// enumSchemaRef returns the schema of an enum type: the values of its
+// constants, inline or as a component with the constant names.
+func (sg *SchemaGenerator) enumSchemaRef(named *types.Named, values []enumValue) *openapi3.SchemaRef {
+	var schema *openapi3.Schema
+	if _, isString := values[0].value.(string); isString {
+		schema = openapi3.NewStringSchema()
+	} else {
+		schema = openapi3.NewIntegerSchema()
+	}
+	sg.describe(schema, named.Obj())
+	var varNames []string
+	for _, v := range values {
+		schema.Enum = append(schema.Enum, v.value)
+		varNames = append(varNames, v.name)
+	}
+	if !sg.config.EnumComponents {
+		return schema.NewRef()
+	}
+
+	name := named.Obj().Name()
+	schema.Extensions = map[string]any{"x-enum-varnames": varNames}
+	if _, components := sg.tables(); components[name] == nil {
+		components[name] = schema.NewRef()
+	}
+	return sg.componentRef(name)
+}
+
 func (sg *SchemaGenerator) schemaForBasic(b *types.Basic) *openapi3.Schema {
 	switch b.Kind() {
 	case types.String:
@@ -125,25 +267,297 @@ func (sg *SchemaGenerator) schemaForBasic(b *types.Basic) *openapi3.Schema {
 }
 
 func (sg *SchemaGenerator) schemaForStruct(s *types.Struct) *openapi3.Schema {
+	fields := jsonFields(s)
+	if sg.config.EmbeddedAllOf {
+		if schema := sg.allOfSchema(s, fields); schema != nil {
+			return schema
+		}
+	}
+
 	schema := openapi3.NewObjectSchema()
 	schema.Properties = make(map[string]*openapi3.SchemaRef)
+	for _, field := range fields {
+		schema.WithPropertyRef(field.name, sg.fieldSchema(field))
+		if sg.isRequired(field) {
+			schema.Required = append(schema.Required, field.name)
+		}
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of a struct field with the constraints of
+// its tags and the description of its doc comment. A $ref is wrapped in an
+// allOf to carry the description.
+func (sg *SchemaGenerator) fieldSchema(field structField) *openapi3.SchemaRef {
+	ref := constrainedSchema(sg.GenerateSchema(field.typ), field.tag)
+	if _, ok := sg.docs[field.v]; !ok {
+		return ref
+	}
+	if ref.Ref != "" || ref.Value == nil {
+		schema := &openapi3.Schema{AllOf: openapi3.SchemaRefs{ref}}
+		sg.describe(schema, field.v)
+		return schema.NewRef()
+	}
+	schema := *ref.Value
+	sg.describe(&schema, field.v)
+	return schema.NewRef()
+}
+
+// describe sets the description of a schema from the doc comment of a type or
+// field. A "Deprecated:" paragraph marks the schema deprecated.
+func (sg *SchemaGenerator) describe(schema *openapi3.Schema, obj types.Object) {
+	doc, ok := sg.docs[obj]
+	if !ok {
+		return
+	}
+	schema.Description = doc
+	schema.Deprecated = isDeprecated(doc)
+}
+
+// isDeprecated reports whether a doc comment has a "Deprecated:" paragraph.
+func isDeprecated(doc string) bool {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(paragraph, "Deprecated:") {
+			return true
+		}
+	}
+	return false
+}
+
+// allOfSchema renders a struct as an allOf of $refs to the named structs it
+// embeds and an object with its remaining fields. An embedded struct is only
+// referenced if all of its fields are promoted unshadowed. Fields embedded
+// through a pointer are not required, unlike in the struct's component, so
+// they stay in the object. It returns nil if no embedded struct can be
+// referenced.
+func (sg *SchemaGenerator) allOfSchema(s *types.Struct, fields []structField) *openapi3.Schema {
+	promoted := make(map[int]int)
+	for _, field := range fields {
+		if field.embed >= 0 {
+			promoted[field.embed]++
+		}
+	}
+
+	schema := &openapi3.Schema{}
+	referenced := make(map[int]bool)
 	for i := 0; i < s.NumFields(); i++ {
-		field := s.Field(i)
-		if !field.Exported() {
+		if promoted[i] == 0 {
 			continue
 		}
-		tag := s.Tag(i)
-		jsonTag := reflect.StructTag(tag).Get("json")
-		parts := strings.Split(jsonTag, ",")
-		fieldName := parts[0]
-		if fieldName == "-" {
+		named, ok := s.Field(i).Type().(*types.Named)
+		if !ok {
 			continue
 		}
-		if fieldName == "" {
-			fieldName = field.Name()
+		if embedded, ok := named.Underlying().(*types.Struct); ok && len(jsonFields(embedded)) == promoted[i] {
+			schema.AllOf = append(schema.AllOf, sg.GenerateSchema(named))
+			referenced[i] = true
 		}
-		fieldSchemaRef := sg.GenerateSchema(field.Type())
-		schema.WithPropertyRef(fieldName, fieldSchemaRef)
+	}
+	if len(referenced) == 0 {
+		return nil
+	}
+
+	own := openapi3.NewObjectSchema()
+	own.Properties = make(map[string]*openapi3.SchemaRef)
+	for _, field := range fields {
+		if field.embed < 0 || !referenced[field.embed] {
+			own.WithPropertyRef(field.name, sg.fieldSchema(field))
+			if sg.isRequired(field) {
+				own.Required = append(own.Required, field.name)
+			}
+		}
+	}
+	if len(own.Properties) > 0 {
+		schema.AllOf = append(schema.AllOf, own.NewRef())
 	}
 	return schema
 }
+
+// structField is a field of a struct as encoding/json encodes it.
+type structField struct {
+	name string
+	v    *types.Var
+	typ  types.Type
+	tag  reflect.StructTag
+	// depth is the embedding depth the field is promoted from, 0 if declared directly.
+	depth int
+	// tagged records that the name comes from a json tag.
+	tagged bool
+	// embed is the index of the top-level embedded field the field is promoted
+	// through, or -1 if declared directly.
+	embed int
+	// omitEmpty records an `omitempty` or `omitzero` json option.
+	omitEmpty bool
+	// viaPointer records that the field is promoted through an embedded
+	// pointer, and is left out when the pointer is nil.
+	viaPointer bool
+	// requiredTag records a `validate:"required"` or `binding:"required"` tag.
+	requiredTag bool
+}
+
+// requiredPolicy returns the required policy of request or response schemas.
+func (sg *SchemaGenerator) requiredPolicy(request bool) string {
+	if request && sg.config.RequestRequired != "" {
+		return sg.config.RequestRequired
+	}
+	if sg.config.ResponseRequired != "" {
+		return sg.config.ResponseRequired
+	}
+	return config.RequiredOmitEmpty
+}
+
+// isRequired reports whether a field is listed as required under the policy
+// of the schema being generated. Fields tagged as required always are.
+func (sg *SchemaGenerator) isRequired(field structField) bool {
+	if field.requiredTag {
+		return true
+	}
+	if sg.requiredPolicy(sg.request) != config.RequiredOmitEmpty {
+		return false
+	}
+	_, isPtr := field.typ.(*types.Pointer)
+	return !isPtr && !field.omitEmpty && !field.viaPointer
+}
+
+// jsonFields returns the fields encoding/json encodes for a struct. The
+// fields of untagged embedded structs, and pointers to them, are promoted. A
+// name declared at several depths is taken from the shallowest; among fields
+// at the same depth a single tagged one wins, and otherwise all are dropped.
+func jsonFields(s *types.Struct) []structField {
+	var all []structField
+	collectJSONFields(s, 0, -1, false, map[*types.Struct]bool{s: true}, &all)
+
+	byName := make(map[string][]structField)
+	var names []string
+	for _, field := range all {
+		if _, ok := byName[field.name]; !ok {
+			names = append(names, field.name)
+		}
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	var fields []structField
+	for _, name := range names {
+		if field, ok := dominantField(byName[name]); ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// collectJSONFields appends the fields of a struct at an embedding depth,
+// descending into untagged embedded structs.
+func collectJSONFields(s *types.Struct, depth, embed int, viaPointer bool, visiting map[*types.Struct]bool, out *[]structField) {
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		tag := reflect.StructTag(s.Tag(i))
+		name, options, _ := strings.Cut(tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Embedded() {
+			t := field.Type()
+			ptr, isPtr := t.(*types.Pointer)
+			if isPtr {
+				t = ptr.Elem()
+			}
+			embedded, isStruct := t.Underlying().(*types.Struct)
+			if !field.Exported() && !isStruct {
+				continue
+			}
+			if name == "" && isStruct {
+				if !visiting[embedded] {
+					promotedFrom := embed
+					if depth == 0 {
+						promotedFrom = i
+					}
+					visiting[embedded] = true
+					collectJSONFields(embedded, depth+1, promotedFrom, viaPointer || isPtr, visiting, out)
+					delete(visiting, embedded)
+				}
+				continue
+			}
+		} else if !field.Exported() {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = field.Name()
+		}
+		*out = append(*out, structField{
+			name:        name,
+			v:           field,
+			typ:         field.Type(),
+			tag:         tag,
+			depth:       depth,
+			tagged:      tagged,
+			embed:       embed,
+			viaPointer:  viaPointer,
+			omitEmpty:   hasTagOption(options, "omitempty") || hasTagOption(options, "omitzero"),
+			requiredTag: isRequiredByTag(tag),
+		})
+	}
+}
+
+// dominantField picks the field encoding/json uses among fields of one name.
+func dominantField(fields []structField) (structField, bool) {
+	depth := fields[0].depth
+	for _, field := range fields {
+		depth = min(depth, field.depth)
+	}
+
+	var candidates []structField
+	for _, field := range fields {
+		if field.depth == depth {
+			candidates = append(candidates, field)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+
+	var tagged []structField
+	for _, field := range candidates {
+		if field.tagged {
+			tagged = append(tagged, field)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return structField{}, false
+}
+
+// sameSchema reports whether two schemas are identical.
+func sameSchema(a, b *openapi3.Schema) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && string(x) == string(y)
+}
+
+// visitSchemaRefs calls visit for every $ref in a schema, descending into
+// inline subschemas.
+func visitSchemaRefs(schema *openapi3.Schema, visit func(*openapi3.SchemaRef)) {
+	if schema == nil {
+		return
+	}
+	var refs []*openapi3.SchemaRef
+	for _, ref := range schema.Properties {
+		refs = append(refs, ref)
+	}
+	refs = append(refs, schema.Items, schema.AdditionalProperties.Schema)
+	refs = append(refs, schema.AllOf...)
+	refs = append(refs, schema.OneOf...)
+	refs = append(refs, schema.AnyOf...)
+	for _, ref := range refs {
+		switch {
+		case ref == nil:
+		case ref.Ref != "":
+			visit(ref)
+		default:
+			visitSchemaRefs(ref.Value, visit)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/Zachacious/go-respec/internal/config"
+)
+
+// checkStruct type-checks src, the body of a package without imports, and
+// returns the struct type named T.
+func checkStruct(t *testing.T, src string) *types.Struct {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "fixture.go", "package fixture\n"+src, 0)
+	if err != nil {
+		t.Fatalf("parsing: %v", err)
+	}
+	pkg, err := new(types.Config).Check("fixture", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("type-checking: %v", err)
+	}
+	return pkg.Scope().Lookup("T").Type().Underlying().(*types.Struct)
+}
+
+func TestJSONFields(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "direct fields",
+			src:  "type T struct { ID int `json:\"id\"`; Name string; Skipped int `json:\"-\"`; hidden int }",
+			want: []string{"id", "Name"},
+		},
+		{
+			name: "promoted fields",
+			src:  "type Base struct { ID int `json:\"id\"` }; type T struct { Base; Name string `json:\"name\"` }",
+			want: []string{"id", "name"},
+		},
+		{
+			name: "unexported embedded struct",
+			src:  "type base struct { ID int `json:\"id\"` }; type T struct { base }",
+			want: []string{"id"},
+		},
+		{
+			name: "tagged embedded struct is a field",
+			src:  "type Base struct { ID int }; type T struct { Base `json:\"base\"` }",
+			want: []string{"base"},
+		},
+		{
+			name: "shallower field shadows",
+			src:  "type Base struct { ID int `json:\"id\"`; Created int `json:\"created\"` }; type T struct { Base; ID string `json:\"id\"` }",
+			want: []string{"id", "created"},
+		},
+		{
+			name: "untagged conflict drops the name",
+			src:  "type A struct { X int }; type B struct { X int }; type T struct { A; B; Y int }",
+			want: []string{"Y"},
+		},
+		{
+			name: "single tagged field wins a conflict",
+			src:  "type A struct { X int `json:\"X\"` }; type B struct { X int }; type T struct { A; B }",
+			want: []string{"X"},
+		},
+		{
+			name: "tagged conflict drops the name",
+			src:  "type A struct { X int `json:\"x\"` }; type B struct { X int `json:\"x\"` }; type T struct { A; B }",
+			want: nil,
+		},
+		{
+			name: "recursive embedding",
+			src:  "type T struct { *T; V int `json:\"v\"` }",
+			want: []string{"v"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, field := range jsonFields(checkStruct(t, tt.src)) {
+				got = append(got, field.name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("jsonFields = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFieldsPromotedThroughPointer(t *testing.T) {
+	s := checkStruct(t, "type Base struct { ID int `json:\"id\"` }; type T struct { Name string; *Base }")
+	fields := jsonFields(s)
+	if len(fields) != 2 {
+		t.Fatalf("jsonFields returned %d fields, want 2", len(fields))
+	}
+	id := fields[1]
+	if id.name != "id" || id.depth != 1 || id.embed != 1 || !id.viaPointer {
+		t.Errorf("id field = %+v, want depth 1 through the pointer embedded at index 1", id)
+	}
+	if name := fields[0]; name.depth != 0 || name.embed != -1 || name.viaPointer {
+		t.Errorf("Name field = %+v, want a direct field", name)
+	}
+}
+
+func TestAllOfSchemaFlattensPointerEmbeds(t *testing.T) {
+	sg := NewSchemaGenerator(&config.SchemaConfig{EmbeddedAllOf: true})
+	schema := sg.schemaForStruct(checkStruct(t, "type Base struct { ID int `json:\"id\"` }; type T struct { *Base; Name string `json:\"name\"` }"))
+
+	if len(schema.AllOf) != 0 {
+		t.Errorf("allOf has %d schemas, want the pointer embed flattened", len(schema.AllOf))
+	}
+	if schema.Properties["id"] == nil || schema.Properties["name"] == nil {
+		t.Errorf("properties = %v, want id and name", slices.Sorted(maps.Keys(schema.Properties)))
+	}
+	if !slices.Equal(schema.Required, []string{"name"}) {
+		t.Errorf("required = %q, want [name]", schema.Required)
+	}
+}
+
+func TestAllOfSchemaReferencesValueEmbeds(t *testing.T) {
+	sg := NewSchemaGenerator(&config.SchemaConfig{EmbeddedAllOf: true})
+	schema := sg.schemaForStruct(checkStruct(t, "type Base struct { ID int `json:\"id\"` }; type T struct { Base; Name string `json:\"name\"` }"))
+
+	if len(schema.AllOf) != 2 {
+		t.Fatalf("allOf has %d schemas, want 2", len(schema.AllOf))
+	}
+	if ref := schema.AllOf[0].Ref; ref != componentRefPrefix+"Base" {
+		t.Errorf("allOf[0] = %q, want a $ref to Base", ref)
+	}
+	if base := sg.Components["Base"]; base == nil || !slices.Equal(base.Value.Required, []string{"id"}) {
+		t.Errorf("Base component = %+v, want id required", base)
+	}
+	if own := schema.AllOf[1].Value; !slices.Equal(own.Required, []string{"name"}) {
+		t.Errorf("own required = %q, want [name]", own.Required)
+	}
+}
+
+func TestDominantField(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []structField
+		want   int // index of the dominant field, -1 for none
+	}{
+		{"single", []structField{{depth: 1}}, 0},
+		{"shallowest wins", []structField{{depth: 2, tagged: true}, {depth: 1}}, 1},
+		{"tagged wins at same depth", []structField{{depth: 1}, {depth: 1, tagged: true}}, 1},
+		{"untagged tie", []structField{{depth: 1}, {depth: 1}}, -1},
+		{"tagged tie", []structField{{depth: 1, tagged: true}, {depth: 1, tagged: true}}, -1},
+		{"deeper tags do not count", []structField{{depth: 1}, {depth: 1}, {depth: 2, tagged: true}}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.fields {
+				tt.fields[i].embed = i
+			}
+			got, ok := dominantField(tt.fields)
+			switch {
+			case tt.want < 0 && ok:
+				t.Errorf("dominantField picked %+v, want none", got)
+			case tt.want >= 0 && (!ok || got.embed != tt.want):
+				t.Errorf("dominantField = %+v, %v, want field %d", got, ok, tt.want)
+			}
+		})
+	}
+}
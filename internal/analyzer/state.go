@@ -5,6 +5,7 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"io"
 
 	"github.com/Zachacious/go-respec/internal/config"
 	"github.com/Zachacious/go-respec/internal/model"
@@ -45,6 +46,16 @@ type Universe struct {
 	// A map of constant objects to their value specifications.
 	// This helps in resolving path segments that are defined as constants.
 	Constants map[types.Object]*ast.ValueSpec
+
+	// A map of type name objects to their declarations.
+	Types map[types.Object]*TypeDecl
+}
+
+// TypeDecl is a type declaration together with its doc comment, which for an
+// ungrouped `type T ...` declaration is attached to the GenDecl.
+type TypeDecl struct {
+	Spec *ast.TypeSpec
+	Doc  *ast.CommentGroup
 }
 
 // ResolvedType represents a type from the config that has been resolved
@@ -60,6 +71,8 @@ type ResolvedType struct {
 // gathered during the multi-phase analysis of the target project.
 type State struct {
 	Fset *token.FileSet
+	// log receives the progress and warnings of the analysis.
+	log io.Writer
 	// The initial loaded packages for the entire project.
 	pkgs []*packages.Package
 
@@ -82,8 +95,14 @@ type State struct {
 	ExprResults map[ast.Expr]*TrackedValue
 	// A map to link variable/parameter objects to the tracked value they hold.
 	VarValues map[types.Object]*TrackedValue
-	// A map to prevent processing the same node for the same value twice, avoiding cycles.
+	// A map of nodes (functions owning router parameters, or the sources of mounted
+	// routers) that are currently being processed, used to avoid cycles.
 	processed map[ast.Node]bool
+	// A map of project functions to the router variable they build and return.
+	RouterFactories map[types.Object]*types.Var
+	// A map of router variables that are mounted into another router. The value
+	// reports whether the router has been attached to its parent yet.
+	mountedRouters map[types.Object]bool
 
 	// The root of the final constructed API route graph.
 	RouteGraph *model.RouteNode
@@ -101,8 +120,8 @@ type State struct {
 	OperationMetadata map[types.Object]*respec.HandlerMetadata
 }
 
-// NewState creates a new State instance.
-func NewState(pkgs []*packages.Package, cfg *config.Config) (*State, error) {
+// NewState creates a new State instance that logs its progress to log.
+func NewState(pkgs []*packages.Package, cfg *config.Config, log io.Writer) (*State, error) {
 	// Create a map to store type information for each AST file.
 	fileInfoMap := make(map[*ast.File]*types.Info)
 	for _, pkg := range pkgs {
@@ -119,20 +138,24 @@ func NewState(pkgs []*packages.Package, cfg *config.Config) (*State, error) {
 
 	s := &State{
 		Fset:                fset,
+		log:                 log,
 		pkgs:                pkgs,
 		fileTypeInfo:        fileInfoMap,
 		ResolvedRouterTypes: make(map[string]*ResolvedType),
 		Universe: &Universe{ // Initialize the universe
 			Functions: make(map[types.Object]*ast.FuncDecl),
 			Constants: make(map[types.Object]*ast.ValueSpec),
+			Types:     make(map[types.Object]*TypeDecl),
 		},
 		// Initialize flow analysis fields
 		Worklist:          make([]WorklistItem, 0),
 		ExprResults:       make(map[ast.Expr]*TrackedValue),
 		VarValues:         make(map[types.Object]*TrackedValue),
 		processed:         make(map[ast.Node]bool),
+		RouterFactories:   make(map[types.Object]*types.Var),
+		mountedRouters:    make(map[types.Object]bool),
 		RouteGraph:        &model.RouteNode{PathPrefix: "/"},
-		SchemaGen:         NewSchemaGenerator(),
+		SchemaGen:         NewSchemaGenerator(cfg.Schemas),
 		Config:            cfg,
 		GroupMetadata:     make(model.GroupMetadataMap),
 		OperationMetadata: make(map[types.Object]*respec.HandlerMetadata),
@@ -0,0 +1,21 @@
+package paths
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// URLParam stands in for a router's path parameter accessor, such as
+// chi.URLParam.
+func URLParam(r *http.Request, key string) string {
+	return r.PathValue(key)
+}
+
+func GetPage(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(URLParam(r, "*"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte(strconv.Itoa(page)))
+}
@@ -0,0 +1,43 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type CreateUserRequest struct {
+	Name string `json:"name"`
+}
+
+// RegisterUser delegates decoding and encoding to helpers two calls deep.
+func RegisterUser(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeCreateUser(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeUser(w, http.StatusCreated, User{Name: req.Name})
+}
+
+func decodeCreateUser(r *http.Request) (CreateUserRequest, error) {
+	var req CreateUserRequest
+	err := decode(r, &req)
+	return req, err
+}
+
+func decode(r *http.Request, v any) error {
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+func writeUser(w http.ResponseWriter, status int, u User) {
+	encode(w, status, u)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	encode(w, status, APIError{Message: err.Error()})
+}
+
+func encode(w http.ResponseWriter, status int, v any) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,62 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type APIError struct {
+	Message string `json:"message"`
+}
+
+type Admin struct {
+	Level int `json:"level"`
+}
+
+// CreateUser writes its error before the success response in source order.
+func CreateUser(w http.ResponseWriter, r *http.Request) {
+	var u User
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIError{Message: err.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(u)
+}
+
+// GetAccount writes two types under the same status.
+func GetAccount(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("admin") != "" {
+		json.NewEncoder(w).Encode(Admin{})
+		return
+	}
+	json.NewEncoder(w).Encode(User{})
+}
+
+// DeleteUser sets a status without writing a body on one path and panics on
+// another.
+func DeleteUser(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("mode") {
+	case "soft":
+		w.WriteHeader(http.StatusAccepted)
+	case "panic":
+		panic("unreachable")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// UpdateUser responds through a helper that branches on its own.
+func UpdateUser(w http.ResponseWriter, r *http.Request) {
+	respond(w, r.URL.Query().Get("id") == "", User{})
+}
+
+func respond(w http.ResponseWriter, missing bool, v any) {
+	if missing {
+		http.Error(w, "missing id", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,23 @@
+package responses
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// writeJSON sets headers before writing the status and body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("X-Request-Id", "abc")
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func GetUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, User{})
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "net/http"
+
+func Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", GetUser)
+	return mux
+}
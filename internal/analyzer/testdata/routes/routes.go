@@ -0,0 +1,31 @@
+package routes
+
+import "net/http"
+
+type route struct {
+	Method  string
+	Path    string
+	Handler http.HandlerFunc
+}
+
+var userRoutes = []route{
+	{Method: "GET", Path: "/users", Handler: ListUsers},
+	{"POST", "/users", CreateUser},
+}
+
+func Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range userRoutes {
+		mux.HandleFunc(rt.Method+" /v1"+rt.Path, rt.Handler)
+	}
+	for path, h := range map[string]func(http.ResponseWriter, *http.Request){
+		"/health": Health,
+	} {
+		mux.Handle("GET "+path, http.HandlerFunc(h))
+	}
+	return mux
+}
+
+func ListUsers(w http.ResponseWriter, r *http.Request)  {}
+func CreateUser(w http.ResponseWriter, r *http.Request) {}
+func Health(w http.ResponseWriter, r *http.Request)     {}
@@ -5,13 +5,14 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"strings"
 )
 
 // discoverUniverse is Phase 2 of the analysis.
 // It scans all files in all packages to build a map of every top-level
-// function and constant declaration.
+// function, constant and type declaration.
 func (s *State) discoverUniverse() {
-	fmt.Println("Phase 2: Discovering project universe (functions and constants)...")
+	fmt.Fprintln(s.log, "Phase 2: Discovering project universe (functions, constants and types)...")
 
 	for _, pkg := range s.pkgs {
 		for _, file := range pkg.Syntax {
@@ -29,15 +30,19 @@ func (s *State) discoverUniverse() {
 					s.registerFunction(info, d)
 				case *ast.GenDecl:
 					// This could be an import, const, var, or type declaration.
-					// We only care about constants for now.
-					if d.Tok == token.CONST {
+					switch d.Tok {
+					case token.CONST:
 						s.registerConstants(info, d)
+					case token.TYPE:
+						s.registerTypes(info, d)
 					}
 				}
 			}
 		}
 	}
-	fmt.Printf("  [Info] Discovered %d functions and %d constants.\n", len(s.Universe.Functions), len(s.Universe.Constants))
+	fmt.Fprintf(s.log, "  [Info] Discovered %d functions, %d constants and %d types.\n", len(s.Universe.Functions), len(s.Universe.Constants), len(s.Universe.Types))
+
+	s.registerEnums()
 }
 
 // registerFunction records a function declaration in the universe map.
@@ -71,3 +76,79 @@ func (s *State) registerConstants(info *types.Info, genDecl *ast.GenDecl) {
 		}
 	}
 }
+
+// registerTypes records all type declarations from a GenDecl block.
+func (s *State) registerTypes(info *types.Info, genDecl *ast.GenDecl) {
+	for _, spec := range genDecl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		obj := info.Defs[ts.Name]
+		if obj == nil {
+			continue
+		}
+		doc := ts.Doc
+		if doc == nil && len(genDecl.Specs) == 1 {
+			doc = genDecl.Doc
+		}
+		s.Universe.Types[obj] = &TypeDecl{Spec: ts, Doc: doc}
+		s.registerDocs(info, obj, doc, ts)
+	}
+}
+
+// registerDocs records the doc comments of a type and of the fields of its
+// struct types, including nested anonymous structs, as schema descriptions.
+// A field is documented by its doc comment or, failing that, its line comment.
+func (s *State) registerDocs(info *types.Info, obj types.Object, doc *ast.CommentGroup, ts *ast.TypeSpec) {
+	if text := strings.TrimSpace(doc.Text()); text != "" {
+		s.SchemaGen.docs[obj] = text
+	}
+	ast.Inspect(ts.Type, func(n ast.Node) bool {
+		st, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, field := range st.Fields.List {
+			fieldDoc := field.Doc
+			if fieldDoc == nil {
+				fieldDoc = field.Comment
+			}
+			text := strings.TrimSpace(fieldDoc.Text())
+			if text == "" {
+				continue
+			}
+			names := field.Names
+			if len(names) == 0 {
+				// An embedded field is defined by its type name.
+				if ident := embeddedTypeIdent(field.Type); ident != nil {
+					names = []*ast.Ident{ident}
+				}
+			}
+			for _, name := range names {
+				if v := info.Defs[name]; v != nil {
+					s.SchemaGen.docs[v] = text
+				}
+			}
+		}
+		return true
+	})
+}
+
+// embeddedTypeIdent returns the identifier naming the type of an embedded
+// field, e.g. `Base` in `*pkg.Base`.
+func embeddedTypeIdent(expr ast.Expr) *ast.Ident {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t
+	case *ast.StarExpr:
+		return embeddedTypeIdent(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel
+	case *ast.IndexExpr:
+		return embeddedTypeIdent(t.X)
+	case *ast.IndexListExpr:
+		return embeddedTypeIdent(t.X)
+	}
+	return nil
+}
@@ -94,6 +94,89 @@ func (s *State) getObjectForExpr(expr ast.Expr) types.Object {
 	return nil
 }
 
+// calleeObject returns the function object invoked by a call. For generic
+// functions it returns the generic declaration rather than the instance.
+func (s *State) calleeObject(call *ast.CallExpr) types.Object {
+	obj := s.getObjectForExpr(call.Fun)
+	if fn, ok := obj.(*types.Func); ok {
+		return fn.Origin()
+	}
+	return obj
+}
+
+// findVarInitializer returns the expression a variable is initialized with in
+// its declaration (`x := expr` or `var x = expr`), or nil if there is none.
+func (s *State) findVarInitializer(obj types.Object) ast.Expr {
+	lhs, rhs, index := s.findVarDeclaration(obj)
+	if index < 0 || len(lhs) != len(rhs) {
+		return nil
+	}
+	return rhs[index]
+}
+
+// findInitializerCall returns the call whose result initializes a variable,
+// and the index of that result, for both `v := f()` and `v, err := f()`.
+func (s *State) findInitializerCall(obj types.Object) (*ast.CallExpr, int) {
+	lhs, rhs, index := s.findVarDeclaration(obj)
+	switch {
+	case index < 0:
+		return nil, -1
+	case len(lhs) == len(rhs):
+		if call, ok := rhs[index].(*ast.CallExpr); ok {
+			return call, 0
+		}
+	case len(rhs) == 1:
+		if call, ok := rhs[0].(*ast.CallExpr); ok {
+			return call, index
+		}
+	}
+	return nil, -1
+}
+
+// findVarDeclaration finds the assignment or value spec declaring a variable
+// and returns its left- and right-hand sides and the variable's index on the
+// left, or -1 if it is not declared by one.
+func (s *State) findVarDeclaration(obj types.Object) ([]ast.Expr, []ast.Expr, int) {
+	if obj == nil || !obj.Pos().IsValid() {
+		return nil, nil, -1
+	}
+	for _, pkg := range s.pkgs {
+		for _, file := range pkg.Syntax {
+			if obj.Pos() < file.Pos() || obj.Pos() >= file.End() {
+				continue
+			}
+			path, _ := astutil.PathEnclosingInterval(file, obj.Pos(), obj.Pos()+token.Pos(len(obj.Name())))
+			if len(path) < 2 {
+				return nil, nil, -1
+			}
+			ident, ok := path[0].(*ast.Ident)
+			if !ok {
+				return nil, nil, -1
+			}
+			switch decl := path[1].(type) {
+			case *ast.AssignStmt:
+				for i, lhs := range decl.Lhs {
+					if lhs == ident {
+						return decl.Lhs, decl.Rhs, i
+					}
+				}
+			case *ast.ValueSpec:
+				names := make([]ast.Expr, len(decl.Names))
+				index := -1
+				for i, name := range decl.Names {
+					names[i] = name
+					if name == ident {
+						index = i
+					}
+				}
+				return names, decl.Values, index
+			}
+			return nil, nil, -1
+		}
+	}
+	return nil, nil, -1
+}
+
 // getFuncPath constructs a fully qualified path for a function object.
 func getFuncPath(obj types.Object) string {
 	if obj == nil {
@@ -192,13 +275,11 @@ func (s *State) resolveStringValue(expr ast.Expr) (string, bool) {
 				return val, true
 			}
 		}
-	case *ast.Ident:
+	case *ast.Ident, *ast.SelectorExpr:
+		// Named constants, including qualified ones such as `http.MethodPatch`.
 		obj := s.getObjectForExpr(e)
-		if constObj, isConst := obj.(*types.Const); isConst {
-			val, err := strconv.Unquote(constObj.Val().String())
-			if err == nil {
-				return val, true
-			}
+		if constObj, isConst := obj.(*types.Const); isConst && constObj.Val().Kind() == constant.String {
+			return constant.StringVal(constObj.Val()), true
 		}
 	case *ast.BinaryExpr:
 		if e.Op == token.ADD {
@@ -0,0 +1,240 @@
+package analyzer
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// validatorFormats maps go-playground/validator rules to schema formats.
+var validatorFormats = map[string]string{
+	"email":            "email",
+	"url":              "uri",
+	"http_url":         "uri",
+	"uri":              "uri",
+	"uuid":             "uuid",
+	"uuid3":            "uuid",
+	"uuid4":            "uuid",
+	"uuid5":            "uuid",
+	"uuid_rfc4122":     "uuid",
+	"uuid3_rfc4122":    "uuid",
+	"uuid4_rfc4122":    "uuid",
+	"uuid5_rfc4122":    "uuid",
+	"hostname":         "hostname",
+	"hostname_rfc1123": "hostname",
+	"fqdn":             "hostname",
+	"ipv4":             "ipv4",
+	"ipv6":             "ipv6",
+}
+
+// validatorPatterns maps go-playground/validator rules to schema patterns.
+var validatorPatterns = map[string]string{
+	"alpha":       "^[a-zA-Z]+$",
+	"alphanum":    "^[a-zA-Z0-9]+$",
+	"numeric":     "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":      "^[0-9]+$",
+	"hexadecimal": "^(?:0[xX])?[0-9a-fA-F]+$",
+	"e164":        "^\\+[1-9]?[0-9]{7,14}$",
+}
+
+// oneofValueRegex matches the values of a `oneof` rule, which are separated
+// by spaces and may be quoted with single quotes.
+var oneofValueRegex = regexp.MustCompile(`'[^']*'|\S+`)
+
+// constrainedSchema returns the schema of a field with the constraints of its
+// `validate` and `binding` tags applied, e.g. `validate:"min=3,max=64,email"`.
+// The schema is copied so the shared schema of the field's type is not
+// altered. $refs are returned unchanged.
+func constrainedSchema(ref *openapi3.SchemaRef, tag reflect.StructTag) *openapi3.SchemaRef {
+	var rules []string
+	for _, key := range []string{"validate", "binding"} {
+		if value := tag.Get(key); value != "" {
+			rules = append(rules, strings.Split(value, ",")...)
+		}
+	}
+	if len(rules) == 0 {
+		return ref
+	}
+	return applyValidationRules(ref, rules)
+}
+
+// isRequiredByTag reports whether a field's `validate` or `binding` tag
+// requires it. Rules after `dive`, including the `keys` to `endkeys` rules of
+// map keys, apply to the elements and do not count.
+func isRequiredByTag(tag reflect.StructTag) bool {
+	for _, key := range []string{"validate", "binding"} {
+		for _, rule := range strings.Split(tag.Get(key), ",") {
+			if rule == "dive" {
+				break
+			}
+			if rule == "required" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyValidationRules applies validator rules to a copy of a schema. Rules
+// after `dive` apply to the items of a slice or the values of a map.
+func applyValidationRules(ref *openapi3.SchemaRef, rules []string) *openapi3.SchemaRef {
+	if ref == nil || ref.Ref != "" || ref.Value == nil {
+		return ref
+	}
+	schema := *ref.Value
+
+	for i := 0; i < len(rules); i++ {
+		rule := rules[i]
+		if rule == "dive" {
+			dived := rules[i+1:]
+			if len(dived) > 0 && dived[0] == "keys" {
+				// Skip the rules of map keys, up to `endkeys`.
+				for len(dived) > 0 && dived[0] != "endkeys" {
+					dived = dived[1:]
+				}
+				if len(dived) > 0 {
+					dived = dived[1:]
+				}
+			}
+			switch {
+			case schema.Type.Is("array"):
+				schema.Items = applyValidationRules(schema.Items, dived)
+			case schema.AdditionalProperties.Schema != nil:
+				schema.AdditionalProperties.Schema = applyValidationRules(schema.AdditionalProperties.Schema, dived)
+			}
+			break
+		}
+		if strings.Contains(rule, "|") {
+			continue // Alternatives cannot be expressed as plain keywords.
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		applyValidationRule(&schema, name, param)
+	}
+	return schema.NewRef()
+}
+
+// applyValidationRule applies a single validator rule to a schema. Bounds
+// constrain the length of strings, the size of arrays and objects, and the
+// value of numbers, as in the validator.
+func applyValidationRule(schema *openapi3.Schema, name, param string) {
+	if format, ok := validatorFormats[name]; ok && schema.Type.Is("string") {
+		schema.Format = format
+		return
+	}
+	if pattern, ok := validatorPatterns[name]; ok && schema.Type.Is("string") {
+		schema.Pattern = pattern
+		return
+	}
+
+	switch name {
+	case "min", "gte":
+		setLowerBound(schema, param, false)
+	case "max", "lte":
+		setUpperBound(schema, param, false)
+	case "gt":
+		setLowerBound(schema, param, true)
+	case "lt":
+		setUpperBound(schema, param, true)
+	case "len":
+		setLowerBound(schema, param, false)
+		setUpperBound(schema, param, false)
+	case "oneof":
+		schema.Enum = nil
+		for _, value := range oneofValueRegex.FindAllString(param, -1) {
+			if enumValue, ok := parseDefaultValue(schema, strings.Trim(value, "'")); ok {
+				schema.Enum = append(schema.Enum, enumValue)
+			}
+		}
+	case "unique":
+		if schema.Type.Is("array") {
+			schema.UniqueItems = true
+		}
+	case "datetime":
+		if schema.Type.Is("string") {
+			schema.Format = "date-time"
+			if param == time.DateOnly {
+				schema.Format = "date"
+			}
+		}
+	case "startswith":
+		if schema.Type.Is("string") {
+			schema.Pattern = "^" + regexp.QuoteMeta(param)
+		}
+	case "endswith":
+		if schema.Type.Is("string") {
+			schema.Pattern = regexp.QuoteMeta(param) + "$"
+		}
+	case "contains":
+		if schema.Type.Is("string") {
+			schema.Pattern = regexp.QuoteMeta(param)
+		}
+	}
+}
+
+// setLowerBound sets the minimum value, length, item or property count.
+func setLowerBound(schema *openapi3.Schema, param string, exclusive bool) {
+	switch {
+	case schema.Type.Is("integer") || schema.Type.Is("number"):
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if exclusive && schema.Type.Is("integer") {
+			n, exclusive = n+1, false
+		}
+		schema.Min = &n
+		schema.ExclusiveMin = exclusive
+	default:
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return
+		}
+		if exclusive {
+			n++
+		}
+		switch {
+		case schema.Type.Is("string"):
+			schema.MinLength = n
+		case schema.Type.Is("array"):
+			schema.MinItems = n
+		case schema.Type.Is("object"):
+			schema.MinProps = n
+		}
+	}
+}
+
+// setUpperBound sets the maximum value, length, item or property count.
+func setUpperBound(schema *openapi3.Schema, param string, exclusive bool) {
+	switch {
+	case schema.Type.Is("integer") || schema.Type.Is("number"):
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if exclusive && schema.Type.Is("integer") {
+			n, exclusive = n-1, false
+		}
+		schema.Max = &n
+		schema.ExclusiveMax = exclusive
+	default:
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil || (exclusive && n == 0) {
+			return
+		}
+		if exclusive {
+			n--
+		}
+		switch {
+		case schema.Type.Is("string"):
+			schema.MaxLength = &n
+		case schema.Type.Is("array"):
+			schema.MaxItems = &n
+		case schema.Type.Is("object"):
+			schema.MaxProps = &n
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestIsRequiredByTag(t *testing.T) {
+	tests := []struct {
+		tag  reflect.StructTag
+		want bool
+	}{
+		{`json:"name"`, false},
+		{`validate:"required"`, true},
+		{`binding:"required"`, true},
+		{`validate:"min=1,required"`, true},
+		{`validate:"required_if=Kind a"`, false},
+		{`validate:"omitempty,email"`, false},
+		{`validate:"dive,required"`, false},
+		{`validate:"required,dive,required"`, true},
+		{`validate:"dive,keys,required,endkeys,required"`, false},
+		{`binding:"dive,required" validate:"required"`, true},
+	}
+	for _, tt := range tests {
+		if got := isRequiredByTag(tt.tag); got != tt.want {
+			t.Errorf("isRequiredByTag(%s) = %v, want %v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestConstrainedSchema(t *testing.T) {
+	f64 := func(f float64) *float64 { return &f }
+	u64 := func(n uint64) *uint64 { return &n }
+
+	tests := []struct {
+		name   string
+		schema *openapi3.Schema
+		tag    reflect.StructTag
+		want   *openapi3.Schema
+	}{
+		{
+			name:   "string length and format",
+			schema: openapi3.NewStringSchema(),
+			tag:    `validate:"required,min=3,max=64,email"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}, MinLength: 3, MaxLength: u64(64), Format: "email"},
+		},
+		{
+			name:   "binding tag",
+			schema: openapi3.NewStringSchema(),
+			tag:    `binding:"uuid4"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}, Format: "uuid"},
+		},
+		{
+			name:   "string pattern",
+			schema: openapi3.NewStringSchema(),
+			tag:    `validate:"alphanum"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}, Pattern: "^[a-zA-Z0-9]+$"},
+		},
+		{
+			name:   "exact length",
+			schema: openapi3.NewStringSchema(),
+			tag:    `validate:"len=2"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}, MinLength: 2, MaxLength: u64(2)},
+		},
+		{
+			name:   "integer bounds",
+			schema: openapi3.NewIntegerSchema(),
+			tag:    `validate:"gte=1,lte=100"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"integer"}, Min: f64(1), Max: f64(100)},
+		},
+		{
+			name:   "exclusive integer bounds become inclusive",
+			schema: openapi3.NewIntegerSchema(),
+			tag:    `validate:"gt=0,lt=10"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"integer"}, Min: f64(1), Max: f64(9)},
+		},
+		{
+			name:   "exclusive number bounds",
+			schema: openapi3.NewFloat64Schema(),
+			tag:    `validate:"gt=0"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"number"}, Min: f64(0), ExclusiveMin: true},
+		},
+		{
+			name:   "oneof",
+			schema: openapi3.NewStringSchema(),
+			tag:    `validate:"oneof=red 'dark blue' green"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}, Enum: []any{"red", "dark blue", "green"}},
+		},
+		{
+			name:   "oneof integers",
+			schema: openapi3.NewIntegerSchema(),
+			tag:    `validate:"oneof=1 2"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"integer"}, Enum: []any{int64(1), int64(2)}},
+		},
+		{
+			name:   "alternatives are skipped",
+			schema: openapi3.NewStringSchema(),
+			tag:    `validate:"email|url"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}},
+		},
+		{
+			name:   "date",
+			schema: openapi3.NewStringSchema(),
+			tag:    `validate:"datetime=2006-01-02"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"string"}, Format: "date"},
+		},
+		{
+			name:   "array items after dive",
+			schema: openapi3.NewArraySchema().WithItems(openapi3.NewStringSchema()),
+			tag:    `validate:"min=1,unique,dive,max=10"`,
+			want: &openapi3.Schema{Type: &openapi3.Types{"array"}, MinItems: 1, UniqueItems: true,
+				Items: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"string"}, MaxLength: u64(10)}}},
+		},
+		{
+			name:   "map values after keys",
+			schema: openapi3.NewObjectSchema().WithAdditionalProperties(openapi3.NewIntegerSchema()),
+			tag:    `validate:"max=5,dive,keys,min=2,endkeys,gte=0"`,
+			want: &openapi3.Schema{Type: &openapi3.Types{"object"}, Properties: openapi3.Schemas{}, MaxProps: u64(5),
+				AdditionalProperties: openapi3.AdditionalProperties{Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: &openapi3.Types{"integer"}, Min: f64(0)}}}},
+		},
+		{
+			name:   "rules of other types are ignored",
+			schema: openapi3.NewBoolSchema(),
+			tag:    `validate:"email,startswith=x"`,
+			want:   &openapi3.Schema{Type: &openapi3.Types{"boolean"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := *tt.schema
+			got := constrainedSchema(tt.schema.NewRef(), tt.tag)
+			if !sameSchema(got.Value, tt.want) {
+				gotJSON, _ := got.Value.MarshalJSON()
+				wantJSON, _ := tt.want.MarshalJSON()
+				t.Errorf("constrainedSchema = %s, want %s", gotJSON, wantJSON)
+			}
+			if !sameSchema(tt.schema, &original) {
+				t.Errorf("constrainedSchema modified the field type's schema")
+			}
+		})
+	}
+}
+
+func TestConstrainedSchemaKeepsRefs(t *testing.T) {
+	ref := openapi3.NewSchemaRef(componentRefPrefix+"User", nil)
+	if got := constrainedSchema(ref, `validate:"required,min=1"`); got != ref {
+		t.Errorf("constrainedSchema = %+v, want the $ref unchanged", got)
+	}
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"gopkg.in/yaml.v3"
@@ -29,6 +31,10 @@ type ParameterPattern struct {
 	FunctionPath string `yaml:"functionPath"`
 	// NameIndex is the index of the name.
 	NameIndex int `yaml:"nameIndex"`
+	// MapIndex marks functions that return a map of all parameters which is
+	// then indexed by name, e.g. gorilla's `mux.Vars(r)["id"]`. NameIndex is
+	// ignored for these.
+	MapIndex bool `yaml:"mapIndex,omitempty"`
 }
 
 // RequestBodyPattern represents a request body pattern.
@@ -39,16 +45,61 @@ type RequestBodyPattern struct {
 	ArgIndex int `yaml:"argIndex"`
 }
 
+// QueryStructPattern represents a function that binds the query string into
+// a struct, e.g. gin's `c.ShouldBindQuery(&filter)`. Each exported field of the
+// struct becomes a query parameter.
+type QueryStructPattern struct {
+	// FunctionPath is the path to the function.
+	FunctionPath string `yaml:"functionPath"`
+	// ArgIndex is the index of the argument pointing to the struct.
+	ArgIndex int `yaml:"argIndex"`
+	// Tags are the struct tags naming the parameters, tried in order. Defaults
+	// to `form`, `query` and `schema`. Untagged fields use the field name.
+	Tags []string `yaml:"tags,omitempty"`
+	// TaggedOnly skips fields without one of the tags, for binders that also
+	// fill the struct from the body (e.g. echo's `c.Bind`).
+	TaggedOnly bool `yaml:"taggedOnly,omitempty"`
+}
+
+// FormFieldPattern represents a function that reads a field of a form request
+// body, e.g. `r.FormValue("name")` or `r.FormFile("avatar")`.
+type FormFieldPattern struct {
+	// FunctionPath is the path to the function.
+	FunctionPath string `yaml:"functionPath"`
+	// NameIndex is the index of the field name.
+	NameIndex int `yaml:"nameIndex"`
+	// File marks functions that read an uploaded file. A file field makes the
+	// body multipart/form-data.
+	File bool `yaml:"file,omitempty"`
+	// Multipart marks functions that parse the body as multipart/form-data,
+	// e.g. `r.ParseMultipartForm(maxMemory)`. NameIndex is ignored for these.
+	Multipart bool `yaml:"multipart,omitempty"`
+}
+
 // ResponseBodyPattern represents a response body pattern.
 type ResponseBodyPattern struct {
 	// FunctionPath is the path to the function.
 	FunctionPath string `yaml:"functionPath"`
-	// DataIndex is the index of the data.
+	// DataIndex is the index of the data. A negative index means the response
+	// has no body (e.g. echo's `c.NoContent(code)`).
 	DataIndex int `yaml:"dataIndex"`
 	// StatusCodeIndex is the index of the status code.
 	StatusCodeIndex *int `yaml:"statusCodeIndex,omitempty"`
 	// DescriptionIndex is the index of the description.
 	DescriptionIndex *int `yaml:"descriptionIndex,omitempty"`
+	// StatusCodeChain takes the status code from a call earlier in the same
+	// method chain, e.g. fiber's `c.Status(201).JSON(data)`. It is used when
+	// StatusCodeIndex is not set.
+	StatusCodeChain *StatusCodeCall `yaml:"statusCodeChain,omitempty"`
+}
+
+// StatusCodeCall describes a call that sets the status code of a response
+// written later in the same method chain.
+type StatusCodeCall struct {
+	// FunctionPath is the path to the function.
+	FunctionPath string `yaml:"functionPath"`
+	// ArgIndex is the index of the status code argument.
+	ArgIndex int `yaml:"argIndex"`
 }
 
 // HandlerPatternsConfig represents a handler patterns configuration.
@@ -59,20 +110,79 @@ type HandlerPatternsConfig struct {
 	ResponseBody []ResponseBodyPattern `yaml:"responseBody"`
 	// QueryParameter is a list of query parameter patterns.
 	QueryParameter []ParameterPattern `yaml:"queryParameter"`
+	// QueryStruct is a list of patterns that bind the query string into a struct.
+	QueryStruct []QueryStructPattern `yaml:"queryStruct"`
+	// FormField is a list of patterns that read fields of form request bodies.
+	FormField []FormFieldPattern `yaml:"formField"`
 	// HeaderParameter is a list of header parameter patterns.
 	HeaderParameter []ParameterPattern `yaml:"headerParameter"`
+	// PathParameter is a list of path parameter patterns, used to infer path parameter types.
+	PathParameter []ParameterPattern `yaml:"pathParameter"`
+}
+
+// PathSyntaxServeMux is the path syntax of the Go 1.22+ net/http ServeMux, whose
+// patterns may carry an HTTP method and host (e.g. "GET example.com/users/{id}")
+// and use `{name...}` wildcards.
+const PathSyntaxServeMux = "servemux"
+
+// PathSyntaxColon is the path syntax of routers that declare parameters as
+// `:name` and match the rest of the path with a `*` or `*name` wildcard
+// (e.g. gin, echo, fiber).
+const PathSyntaxColon = "colon"
+
+// EndpointRegistration describes an endpoint method whose HTTP method is not
+// its name, e.g. chi's `r.Method("PATCH", path, h)` or `r.HandleFunc(path, h)`.
+type EndpointRegistration struct {
+	// Name is the name of the method.
+	Name string `yaml:"name"`
+	// MethodIndex is the index of the HTTP method argument, if the method is passed as an argument.
+	MethodIndex *int `yaml:"methodIndex,omitempty"`
+	// PathIndex is the index of the path argument.
+	PathIndex int `yaml:"pathIndex"`
+	// HandlerIndex is the index of the handler argument. Negative values count
+	// from the end of the argument list (-1 is the last argument).
+	HandlerIndex int `yaml:"handlerIndex"`
+	// AnyMethod marks registrations that match every HTTP method.
+	AnyMethod bool `yaml:"anyMethod,omitempty"`
 }
 
 // RouterDefinition represents a router definition.
 type RouterDefinition struct {
 	// Type is the type of the router.
 	Type string `yaml:"type"`
-	// EndpointMethods is a list of endpoint methods.
+	// EndpointMethods is a list of endpoint methods named after the HTTP method
+	// they register (e.g. "Get"), taking the path and handler as arguments.
 	EndpointMethods []string `yaml:"endpointMethods"`
+	// EndpointHandlerIndex is the index of the handler argument of
+	// EndpointMethods, 1 if unset. Negative values count from the end, for
+	// routers that take middleware before the handler (e.g. fiber's
+	// `app.Get(path, mw, handler)`).
+	EndpointHandlerIndex *int `yaml:"endpointHandlerIndex,omitempty"`
+	// EndpointRegistrations is a list of endpoint methods with a custom argument layout.
+	EndpointRegistrations []EndpointRegistration `yaml:"endpointRegistrations,omitempty"`
+	// AnyMethodVerbs is the list of HTTP methods an operation is generated for
+	// when a registration matches every method. A built-in list is used if empty.
+	AnyMethodVerbs []string `yaml:"anyMethodVerbs,omitempty"`
 	// GroupMethods is a list of group methods.
 	GroupMethods []string `yaml:"groupMethods"`
 	// MiddlewareWrapperMethods is a list of middleware wrapper methods.
 	MiddlewareWrapperMethods []string `yaml:"middlewareWrapperMethods"`
+	// MountMethods is a list of methods that mount a sub-router under a path prefix.
+	MountMethods []string `yaml:"mountMethods"`
+	// SubrouterMethods is a list of methods chained onto a group method's result
+	// that turn it into a router, e.g. gorilla's `r.PathPrefix("/api").Subrouter()`.
+	SubrouterMethods []string `yaml:"subrouterMethods,omitempty"`
+	// MethodChainMethods is a list of methods chained onto an endpoint
+	// registration that set its HTTP methods, e.g. gorilla's `.Methods("GET")`.
+	MethodChainMethods []string `yaml:"methodChainMethods,omitempty"`
+	// PathSyntax is the syntax of the router's path patterns: PathSyntaxColon,
+	// PathSyntaxServeMux, or empty for `{name}` and `{name:regex}` variables.
+	// With PathSyntaxServeMux, an HTTP method in the pattern takes precedence
+	// over the method of the registration.
+	PathSyntax string `yaml:"pathSyntax,omitempty"`
+	// PackageEndpointFunctions is a list of package-level functions that register
+	// endpoints on a default router instance (e.g. "net/http.HandleFunc").
+	PackageEndpointFunctions []string `yaml:"packageEndpointFunctions,omitempty"`
 }
 
 // Config represents a configuration.
@@ -89,8 +199,40 @@ type Config struct {
 	SecurityPatterns []SecurityPattern `yaml:"securityPatterns"`
 	// Servers is a list of server URLs.
 	Servers []ServerUrl `yaml:"servers,omitempty"`
+	// Schemas configures how Go types are turned into schemas.
+	Schemas *SchemaConfig `yaml:"schemas,omitempty"`
 }
 
+// SchemaConfig configures schema generation.
+type SchemaConfig struct {
+	// EmbeddedAllOf renders a struct that embeds a named struct as an allOf of
+	// a $ref to the embedded struct's component and the struct's own fields,
+	// instead of promoting the embedded fields into its properties. Embedded
+	// structs whose fields are shadowed, and pointers to structs, whose fields
+	// are left out when the pointer is nil, are still flattened.
+	EmbeddedAllOf bool `yaml:"embeddedAllOf,omitempty"`
+	// EnumComponents renders named types with constants as reusable enum
+	// components carrying the constant names in `x-enum-varnames`, instead of
+	// inlining the enum in every schema that uses them.
+	EnumComponents bool `yaml:"enumComponents,omitempty"`
+	// RequestRequired is the required policy of request body schemas, the
+	// ResponseRequired policy if empty. Types whose schemas differ under the
+	// two policies get a separate "<Name>Request" component.
+	RequestRequired string `yaml:"requestRequired,omitempty"`
+	// ResponseRequired is the required policy of all other schemas,
+	// RequiredOmitEmpty if empty.
+	ResponseRequired string `yaml:"responseRequired,omitempty"`
+}
+
+// Required policies select the struct fields listed as required in a schema.
+const (
+	// RequiredTags lists fields tagged `validate:"required"` or `binding:"required"`.
+	RequiredTags = "tags"
+	// RequiredOmitEmpty also lists non-pointer fields without `omitempty`,
+	// which encoding/json always writes.
+	RequiredOmitEmpty = "omitempty"
+)
+
 // Load loads a configuration from a file.
 func Load(projectPath string) (*Config, error) {
 	// Helper for making statusCodeIndex optional
@@ -100,16 +242,102 @@ func Load(projectPath string) (*Config, error) {
 		Info: &openapi3.Info{Title: "API Documentation", Version: "1.0.0"},
 		RouterDefinitions: []RouterDefinition{
 			{
-				Type:                     "github.com/go-chi/chi/v5.Mux",
-				EndpointMethods:          []string{"Get", "Post", "Put", "Patch", "Delete", "Head", "Options", "Trace"},
+				Type:            "github.com/go-chi/chi/v5.Mux",
+				EndpointMethods: []string{"Get", "Post", "Put", "Patch", "Delete", "Head", "Options", "Trace"},
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Method", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: 2},
+					{Name: "MethodFunc", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: 2},
+					{Name: "Handle", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+					{Name: "HandleFunc", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+				},
 				GroupMethods:             []string{"Route", "Group"},
 				MiddlewareWrapperMethods: []string{"With", "Use"},
+				MountMethods:             []string{"Mount"},
+			},
+			{
+				Type:                 "github.com/gin-gonic/gin.Engine",
+				EndpointMethods:      []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+				EndpointHandlerIndex: intPtr(-1),
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Handle", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: -1},
+					{Name: "Any", PathIndex: 0, HandlerIndex: -1, AnyMethod: true},
+				},
+				GroupMethods:             []string{"Group"},
+				MiddlewareWrapperMethods: []string{"Use"},
+				PathSyntax:               PathSyntaxColon,
+			},
+			{
+				Type:                 "github.com/gin-gonic/gin.RouterGroup",
+				EndpointMethods:      []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+				EndpointHandlerIndex: intPtr(-1),
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Handle", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: -1},
+					{Name: "Any", PathIndex: 0, HandlerIndex: -1, AnyMethod: true},
+				},
+				GroupMethods:             []string{"Group"},
+				MiddlewareWrapperMethods: []string{"Use"},
+				PathSyntax:               PathSyntaxColon,
 			},
 			{
-				Type:                     "github.com/gin-gonic/gin.Engine",
-				EndpointMethods:          []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
+				Type:            "github.com/labstack/echo/v4.Echo",
+				EndpointMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "CONNECT", "TRACE"},
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Any", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+					{Name: "Match", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: 2},
+					{Name: "Add", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: 2},
+				},
 				GroupMethods:             []string{"Group"},
+				MiddlewareWrapperMethods: []string{"Use"},
+				PathSyntax:               PathSyntaxColon,
+			},
+			{
+				Type:            "github.com/labstack/echo/v4.Group",
+				EndpointMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "CONNECT", "TRACE"},
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Any", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+					{Name: "Match", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: 2},
+					{Name: "Add", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: 2},
+				},
+				GroupMethods:             []string{"Group"},
+				MiddlewareWrapperMethods: []string{"Use"},
+				PathSyntax:               PathSyntaxColon,
+			},
+			{
+				Type:                 "github.com/gofiber/fiber/v2.App",
+				EndpointMethods:      []string{"Get", "Post", "Put", "Patch", "Delete", "Head", "Options", "Connect", "Trace"},
+				EndpointHandlerIndex: intPtr(-1),
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "All", PathIndex: 0, HandlerIndex: -1, AnyMethod: true},
+					{Name: "Add", MethodIndex: intPtr(0), PathIndex: 1, HandlerIndex: -1},
+				},
+				GroupMethods:             []string{"Group", "Route"},
+				MiddlewareWrapperMethods: []string{"Use"},
+				MountMethods:             []string{"Mount"},
+				PathSyntax:               PathSyntaxColon,
+			},
+			{
+				Type:            "github.com/gorilla/mux.Router",
+				EndpointMethods: []string{},
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Handle", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+					{Name: "HandleFunc", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+				},
+				GroupMethods:             []string{"PathPrefix"},
+				MiddlewareWrapperMethods: []string{"Use"},
+				SubrouterMethods:         []string{"Subrouter"},
+				MethodChainMethods:       []string{"Methods"},
+			},
+			{
+				Type:            "net/http.ServeMux",
+				EndpointMethods: []string{},
+				EndpointRegistrations: []EndpointRegistration{
+					{Name: "Handle", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+					{Name: "HandleFunc", PathIndex: 0, HandlerIndex: 1, AnyMethod: true},
+				},
+				GroupMethods:             []string{},
 				MiddlewareWrapperMethods: []string{},
+				PathSyntax:               PathSyntaxServeMux,
+				PackageEndpointFunctions: []string{"net/http.Handle", "net/http.HandleFunc"},
 			},
 		},
 		SecuritySchemes: make(map[string]any),
@@ -128,27 +356,76 @@ func Load(projectPath string) (*Config, error) {
 				{FunctionPath: "encoding/json.Decoder.Decode", ArgIndex: 0},
 				{FunctionPath: "github.com/gin-gonic/gin.Context.ShouldBindJSON", ArgIndex: 0},
 				{FunctionPath: "github.com/labstack/echo/v4.Context.Bind", ArgIndex: 0},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.BodyParser", ArgIndex: 0},
 			},
 			ResponseBody: []ResponseBodyPattern{
 				// Standard library pattern
 				{FunctionPath: "encoding/json.Encoder.Encode", DataIndex: 0},
 				// Gin-like pattern
 				{FunctionPath: "github.com/gin-gonic/gin.Context.JSON", StatusCodeIndex: intPtr(0), DataIndex: 1},
+				// Echo patterns
+				{FunctionPath: "github.com/labstack/echo/v4.Context.JSON", StatusCodeIndex: intPtr(0), DataIndex: 1},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.JSONPretty", StatusCodeIndex: intPtr(0), DataIndex: 1},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.NoContent", StatusCodeIndex: intPtr(0), DataIndex: -1},
+				// Fiber patterns
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.JSON", DataIndex: 0, StatusCodeChain: &StatusCodeCall{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.Status", ArgIndex: 0}},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.SendStatus", StatusCodeIndex: intPtr(0), DataIndex: -1},
 				// Common custom helper patterns (like in your project)
 				{FunctionPath: "github.com/zachacious/justauth/internal/utils.RespondWithJSON", StatusCodeIndex: intPtr(1), DataIndex: 2},
 				{FunctionPath: "github.com/zachacious/justauth/internal/utils.RespondWithError", StatusCodeIndex: intPtr(1), DescriptionIndex: intPtr(2), DataIndex: 3},
 			},
 
 			QueryParameter: []ParameterPattern{
-				{FunctionPath: "net/http.URL.Query.Get", NameIndex: 0},
+				{FunctionPath: "net/url.Values.Get", NameIndex: 0},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.QueryParam", NameIndex: 0},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.Query", NameIndex: 0},
+			},
+			QueryStruct: []QueryStructPattern{
+				{FunctionPath: "github.com/gin-gonic/gin.Context.ShouldBindQuery", ArgIndex: 0, Tags: []string{"form"}},
+				{FunctionPath: "github.com/gin-gonic/gin.Context.BindQuery", ArgIndex: 0, Tags: []string{"form"}},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.Bind", ArgIndex: 0, Tags: []string{"query"}, TaggedOnly: true},
+				{FunctionPath: "github.com/labstack/echo/v4.DefaultBinder.BindQueryParams", ArgIndex: 1, Tags: []string{"query"}, TaggedOnly: true},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.QueryParser", ArgIndex: 0, Tags: []string{"query"}},
+				{FunctionPath: "github.com/gorilla/schema.Decoder.Decode", ArgIndex: 0, Tags: []string{"schema"}},
+			},
+			FormField: []FormFieldPattern{
+				{FunctionPath: "net/http.Request.FormValue", NameIndex: 0},
+				{FunctionPath: "net/http.Request.PostFormValue", NameIndex: 0},
+				{FunctionPath: "net/http.Request.FormFile", NameIndex: 0, File: true},
+				{FunctionPath: "net/http.Request.ParseMultipartForm", Multipart: true},
+				{FunctionPath: "net/http.Request.MultipartReader", Multipart: true},
+				{FunctionPath: "github.com/gin-gonic/gin.Context.PostForm", NameIndex: 0},
+				{FunctionPath: "github.com/gin-gonic/gin.Context.DefaultPostForm", NameIndex: 0},
+				{FunctionPath: "github.com/gin-gonic/gin.Context.FormFile", NameIndex: 0, File: true},
+				{FunctionPath: "github.com/gin-gonic/gin.Context.MultipartForm", Multipart: true},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.FormValue", NameIndex: 0},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.FormFile", NameIndex: 0, File: true},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.MultipartForm", Multipart: true},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.FormValue", NameIndex: 0},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.FormFile", NameIndex: 0, File: true},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.MultipartForm", Multipart: true},
 			},
 			HeaderParameter: []ParameterPattern{
 				{FunctionPath: "net/http.Header.Get", NameIndex: 0},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.Get", NameIndex: 0},
+			},
+			PathParameter: []ParameterPattern{
+				{FunctionPath: "github.com/go-chi/chi/v5.URLParam", NameIndex: 1},
+				{FunctionPath: "net/http.Request.PathValue", NameIndex: 0},
+				{FunctionPath: "github.com/gorilla/mux.Vars", MapIndex: true},
+				{FunctionPath: "github.com/labstack/echo/v4.Context.Param", NameIndex: 0},
+				{FunctionPath: "github.com/gin-gonic/gin.Context.Param", NameIndex: 0},
+				{FunctionPath: "github.com/gofiber/fiber/v2.Ctx.Params", NameIndex: 0},
 			},
 		},
 		Servers: []ServerUrl{},
 	}
 
+	// Handler patterns from the file are added to the built-in ones rather
+	// than replacing them, as YAML lists would.
+	builtinPatterns := cfg.HandlerPatterns
+	cfg.HandlerPatterns = &HandlerPatternsConfig{}
+
 	configPath := filepath.Join(projectPath, ".respec.yaml")
 	data, err := os.ReadFile(configPath)
 	if err == nil {
@@ -158,10 +435,62 @@ func Load(projectPath string) (*Config, error) {
 	} else if !os.IsNotExist(err) {
 		return nil, err
 	}
+	cfg.HandlerPatterns = mergeHandlerPatterns(builtinPatterns, cfg.HandlerPatterns)
+
+	if cfg.Schemas != nil {
+		if err := validateRequiredPolicy("requestRequired", cfg.Schemas.RequestRequired); err != nil {
+			return nil, err
+		}
+		if err := validateRequiredPolicy("responseRequired", cfg.Schemas.ResponseRequired); err != nil {
+			return nil, err
+		}
+	}
 
 	return cfg, nil
 }
 
+// mergeHandlerPatterns returns the built-in handler patterns followed by the
+// user's. A user pattern replaces the built-in pattern for the same function.
+func mergeHandlerPatterns(builtin, user *HandlerPatternsConfig) *HandlerPatternsConfig {
+	if user == nil {
+		return builtin
+	}
+	return &HandlerPatternsConfig{
+		RequestBody:     mergePatterns(builtin.RequestBody, user.RequestBody, func(p RequestBodyPattern) string { return p.FunctionPath }),
+		ResponseBody:    mergePatterns(builtin.ResponseBody, user.ResponseBody, func(p ResponseBodyPattern) string { return p.FunctionPath }),
+		QueryParameter:  mergePatterns(builtin.QueryParameter, user.QueryParameter, func(p ParameterPattern) string { return p.FunctionPath }),
+		QueryStruct:     mergePatterns(builtin.QueryStruct, user.QueryStruct, func(p QueryStructPattern) string { return p.FunctionPath }),
+		FormField:       mergePatterns(builtin.FormField, user.FormField, func(p FormFieldPattern) string { return p.FunctionPath }),
+		HeaderParameter: mergePatterns(builtin.HeaderParameter, user.HeaderParameter, func(p ParameterPattern) string { return p.FunctionPath }),
+		PathParameter:   mergePatterns(builtin.PathParameter, user.PathParameter, func(p ParameterPattern) string { return p.FunctionPath }),
+	}
+}
+
+// mergePatterns appends user patterns to built-in ones, replacing a built-in
+// pattern with a user pattern for the same function.
+func mergePatterns[T any](builtin, user []T, functionPath func(T) string) []T {
+	merged := slices.Clone(builtin)
+	for _, p := range user {
+		i := slices.IndexFunc(builtin, func(b T) bool { return functionPath(b) == functionPath(p) })
+		if i >= 0 {
+			merged[i] = p
+		} else {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// validateRequiredPolicy returns an error if a required policy is set to an
+// unknown value.
+func validateRequiredPolicy(key, policy string) error {
+	switch policy {
+	case "", RequiredTags, RequiredOmitEmpty:
+		return nil
+	}
+	return fmt.Errorf("schemas.%s: unknown policy %q, must be %s or %s", key, policy, RequiredTags, RequiredOmitEmpty)
+}
+
 // GetSecuritySchemes is a helper to get sanitized security schemes.
 func (c *Config) GetSecuritySchemes() openapi3.SecuritySchemes {
 	schemes := make(openapi3.SecuritySchemes)
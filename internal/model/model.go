@@ -1,6 +1,7 @@
 package model
 
 import (
+	"go/ast"
 	"go/types"
 
 	"github.com/Zachacious/go-respec/respec"
@@ -47,10 +48,18 @@ type Operation struct {
 	FullPath string
 	// HandlerPackage is the package name of the handler function.
 	HandlerPackage string
-	// HandlerName is the name of the handler function.
+	// HandlerName is the name of the handler function, or a name derived from
+	// the method and path for a function literal.
 	HandlerName string
-	// GoHandler holds a reference to the Go handler function.
+	// GoHandler holds a reference to the Go handler function. For handlers
+	// built by a factory (e.g. `h.List()`) it is the factory, and it is nil for
+	// inline function literals.
 	GoHandler types.Object
+	// HandlerBody is the body of the function that handles requests, if it is
+	// part of the project.
+	HandlerBody *ast.BlockStmt
+	// HandlerDoc is the doc comment of the handler or its factory.
+	HandlerDoc *ast.CommentGroup
 	// HandlerMetadata holds metadata from the fluent builder.
 	HandlerMetadata *respec.HandlerMetadata
 	// Spec is the OpenAPI specification of the API endpoint.